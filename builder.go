@@ -0,0 +1,110 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaBuilderTypeName = "strings.Builder"
+
+func registerBuilderType(L *lua.LState, mod *lua.LTable) {
+	mt := L.NewTypeMetatable(luaBuilderTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), builderMethods))
+	L.SetField(mt, "__len", L.NewFunction(builderMethods["Len"]))
+	L.SetField(mt, "__tostring", L.NewFunction(builderMethods["String"]))
+
+	L.SetField(mod, "NewBuilder", L.NewFunction(newBuilder))
+}
+
+func newBuilder(L *lua.LState) int {
+	ud := L.NewUserData()
+	ud.Value = new(strings.Builder)
+	L.SetMetatable(ud, L.GetTypeMetatable(luaBuilderTypeName))
+	L.Push(ud)
+	return 1
+}
+
+// CheckBuilder returns the *strings.Builder held by the userdata at
+// position n of the stack, raising an argument error otherwise.
+func CheckBuilder(L *lua.LState, n int) *strings.Builder {
+	ud := L.CheckUserData(n)
+	if b, ok := ud.Value.(*strings.Builder); ok {
+		return b
+	}
+	L.ArgError(n, "strings.Builder expected")
+	return nil
+}
+
+// ToBuilder returns the *strings.Builder held by lv, if any.
+func ToBuilder(lv lua.LValue) (*strings.Builder, bool) {
+	if ud, ok := lv.(*lua.LUserData); ok {
+		b, ok := ud.Value.(*strings.Builder)
+		return b, ok
+	}
+	return nil, false
+}
+
+var builderMethods = map[string]lua.LGFunction{
+	"Cap": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+
+		ret := b.Cap()
+		return helper.RetInt(L, ret)
+	},
+	"Grow": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+		n := L.CheckInt(2)
+
+		if n < 0 {
+			L.ArgError(2, "negative count")
+		}
+
+		b.Grow(n)
+		return 0
+	},
+	"Len": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+
+		ret := b.Len()
+		return helper.RetInt(L, ret)
+	},
+	"Reset": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+
+		b.Reset()
+		return 0
+	},
+	"String": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+
+		ret := b.String()
+		return helper.RetString(L, ret)
+	},
+	"WriteByte": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+		c := checkByte(L, 2)
+
+		b.WriteByte(c)
+		return 0
+	},
+	"WriteRune": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+		r := checkRune(L, 2)
+
+		ret, _ := b.WriteRune(r)
+		return helper.RetInt(L, ret)
+	},
+	"WriteString": func(L *lua.LState) int {
+		b := CheckBuilder(L, 1)
+		s := L.CheckString(2)
+
+		ret, _ := b.WriteString(s)
+		return helper.RetInt(L, ret)
+	},
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestBuilder(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+
+	err := L.DoString(`
+		local strings = require("strings")
+
+		b = strings.NewBuilder()
+		n1 = b:WriteString("hello")
+		b:WriteByte(string.byte(","))
+		n2 = b:WriteRune(0x4E16)
+		n3 = b:WriteRune(0x754C)
+		length = b:Len()
+		size = #b
+		text = tostring(b)
+		value = b:String()
+	`)
+	require.NoError(t, err)
+
+	var expected strings.Builder
+	expected.WriteString("hello")
+	expected.WriteByte(',')
+	expected.WriteRune('世')
+	expected.WriteRune('界')
+
+	require.Equal(t, lua.LNumber(5), L.GetGlobal("n1"))
+	require.Equal(t, lua.LNumber(3), L.GetGlobal("n2"))
+	require.Equal(t, lua.LNumber(3), L.GetGlobal("n3"))
+	require.Equal(t, lua.LNumber(expected.Len()), L.GetGlobal("length"))
+	require.Equal(t, lua.LNumber(expected.Len()), L.GetGlobal("size"))
+	require.Equal(t, lua.LString(expected.String()), L.GetGlobal("text"))
+	require.Equal(t, lua.LString(expected.String()), L.GetGlobal("value"))
+
+	// the host reads the accumulated builder back
+	b, ok := lua_strings.ToBuilder(L.GetGlobal("b"))
+	require.True(t, ok)
+	require.Equal(t, expected.String(), b.String())
+
+	_, ok = lua_strings.ToBuilder(lua.LString("b"))
+	require.False(t, ok)
+}
+
+func TestBuilderGrowReset(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+
+	err := L.DoString(`
+		local strings = require("strings")
+
+		local b = strings.NewBuilder()
+		b:Grow(64)
+		assert(b:Cap() >= 64)
+		assert(b:Len() == 0)
+
+		b:WriteString("abc")
+		b:Reset()
+		assert(b:Len() == 0)
+		assert(b:String() == "")
+	`)
+	require.NoError(t, err)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		msg  string
+	}{
+		{`strings.NewBuilder():Grow(-1)`, "negative count"},
+		{`strings.NewBuilder():WriteByte(256)`, "byte out of range"},
+		{`strings.NewBuilder():WriteByte(-1)`, "byte out of range"},
+		{`strings.NewBuilder().WriteString("x", "y")`, "userdata expected"},
+		{`strings.NewBuilder().Len(io.stdout)`, "strings.Builder expected"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(`local strings = require("strings")` + "\n" + tests[i].code)
+		require.Error(t, err, "case %d: %s", i, tests[i].code)
+		require.Contains(t, err.Error(), tests[i].msg, "case %d: %s", i, tests[i].code)
+
+		L.Close()
+	}
+}
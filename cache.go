@@ -0,0 +1,49 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"sync"
+)
+
+// compileCache keeps compiled expressions, which are immutable and safe
+// to share between LStates. It is emptied when it is full.
+type compileCache[K comparable, V any] struct {
+	mu   sync.RWMutex
+	size int
+	m    map[K]V
+}
+
+func newCompileCache[K comparable, V any](size int) *compileCache[K, V] {
+	return &compileCache[K, V]{size: size, m: make(map[K]V)}
+}
+
+// get returns the value cached for key, calling compile on a miss.
+// Errors are not cached. compile runs outside the lock, so LStates
+// missing the same key at once may each compile it; one value is kept.
+func (c *compileCache[K, V]) get(key K, compile func() (V, error)) (V, error) {
+	c.mu.RLock()
+	v, ok := c.m[key]
+	c.mu.RUnlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := compile()
+	if err != nil {
+		return v, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.m[key]; ok {
+		return cached, nil
+	}
+	if len(c.m) >= c.size {
+		c.m = make(map[K]V)
+	}
+	c.m[key] = v
+	return v, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+	"unicode"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// specialCases are the named case mappings of the *Special functions
+// and Options.CaseLocale.
+var specialCases = map[string]unicode.SpecialCase{
+	"azeri":   unicode.AzeriCase,
+	"turkish": unicode.TurkishCase,
+}
+
+func checkSpecialCase(L *lua.LState, n int) unicode.SpecialCase {
+	name := L.CheckString(n)
+	c, ok := specialCases[name]
+	if !ok {
+		L.ArgError(n, "unknown special case '"+name+"'")
+	}
+	return c
+}
+
+var specialCaseFuncs = map[string]lua.LGFunction{
+	"ToLowerSpecial": func(L *lua.LState) int {
+		c := checkSpecialCase(L, 1)
+		s := L.CheckString(2)
+
+		ret := strings.ToLowerSpecial(c, s)
+		return helper.RetString(L, ret)
+	},
+	"ToTitleSpecial": func(L *lua.LState) int {
+		c := checkSpecialCase(L, 1)
+		s := L.CheckString(2)
+
+		ret := strings.ToTitleSpecial(c, s)
+		return helper.RetString(L, ret)
+	},
+	"ToUpperSpecial": func(L *lua.LState) int {
+		c := checkSpecialCase(L, 1)
+		s := L.CheckString(2)
+
+		ret := strings.ToUpperSpecial(c, s)
+		return helper.RetString(L, ret)
+	},
+}
+
+// localeCaseFuncs returns ToLower, ToTitle, ToUpper and TitleCase using
+// the case mapping c.
+func localeCaseFuncs(c unicode.SpecialCase) map[string]lua.LGFunction {
+	return map[string]lua.LGFunction{
+		"TitleCase": newTitleCase(c),
+		"ToLower": func(L *lua.LState) int {
+			s := L.CheckString(1)
+
+			ret := strings.ToLowerSpecial(c, s)
+			return helper.RetString(L, ret)
+		},
+		"ToTitle": func(L *lua.LState) int {
+			s := L.CheckString(1)
+
+			ret := strings.ToTitleSpecial(c, s)
+			return helper.RetString(L, ret)
+		},
+		"ToUpper": func(L *lua.LState) int {
+			s := L.CheckString(1)
+
+			ret := strings.ToUpperSpecial(c, s)
+			return helper.RetString(L, ret)
+		},
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestSpecialCase(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      string
+		s      string
+		goFunc func(c unicode.SpecialCase, s string) string
+	}{
+		{"ToUpperSpecial", "turkish", "istanbul", strings.ToUpperSpecial},
+		{"ToUpperSpecial", "azeri", "bakı, iş", strings.ToUpperSpecial},
+		{"ToLowerSpecial", "turkish", "İSTANBUL IRMAK", strings.ToLowerSpecial},
+		{"ToLowerSpecial", "azeri", "İI", strings.ToLowerSpecial},
+		{"ToTitleSpecial", "turkish", "iyi", strings.ToTitleSpecial},
+		{"ToTitleSpecial", "azeri", "", strings.ToTitleSpecial},
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+	require.NoError(t, L.DoString(`strings = require("strings")`))
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/func=%s", i, tt.name), func(t *testing.T) {
+			c := unicode.TurkishCase
+			if tt.c == "azeri" {
+				c = unicode.AzeriCase
+			}
+			expected := tt.goFunc(c, tt.s)
+
+			L.Push(L.GetField(L.GetGlobal("strings"), tt.name))
+			L.Push(lua.LString(tt.c))
+			L.Push(lua.LString(tt.s))
+			L.Call(2, 1)
+			got := L.Get(-1)
+			L.Pop(1)
+
+			require.Equal(t, lua.LString(expected), got,
+				"case %d: Lua returned %q but Go returned %q (case: %s, string: %q)",
+				i, got, expected, tt.c, tt.s)
+		})
+	}
+
+	err := L.DoString(`strings.ToUpperSpecial("klingon", "i")`)
+	require.ErrorContains(t, err, "bad argument #1 to ToUpperSpecial (unknown special case 'klingon')")
+}
+
+func TestCaseLocale(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		StringMethods: true,
+		CaseLocale:    "turkish",
+	})
+	L.PreloadModule("strings0", lua_strings.Loader)
+
+	err := L.DoString(`
+		local strings = require("strings")
+		local strings0 = require("strings0")
+
+		assert(strings.ToUpper("iı") == "İI")
+		assert(strings.ToLower("İI") == "iı")
+		assert(strings.ToTitle("i") == "İ")
+		assert(("i"):ToUpper() == "İ")
+		assert(("i"):ToUpperSpecial("azeri") == "İ")
+		assert(strings0.ToUpper("i") == "I")
+	`)
+	require.NoError(t, err)
+
+	L.PreloadModule("strings1", lua_strings.NewLoader(lua_strings.Options{
+		CaseLocale: "klingon",
+	}))
+	err = L.DoString(`require("strings1")`)
+	require.ErrorContains(t, err, "strings: unknown casing locale 'klingon'")
+}
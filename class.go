@@ -0,0 +1,67 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"unicode"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// runeClasses are the character classes of the unicode.Is* functions.
+var runeClasses = map[string]func(rune) bool{
+	"control": unicode.IsControl,
+	"digit":   unicode.IsDigit,
+	"graphic": unicode.IsGraphic,
+	"letter":  unicode.IsLetter,
+	"lower":   unicode.IsLower,
+	"mark":    unicode.IsMark,
+	"number":  unicode.IsNumber,
+	"print":   unicode.IsPrint,
+	"punct":   unicode.IsPunct,
+	"space":   unicode.IsSpace,
+	"symbol":  unicode.IsSymbol,
+	"title":   unicode.IsTitle,
+	"upper":   unicode.IsUpper,
+}
+
+// lookupRuneClass resolves a class of runeClasses, or the name of a
+// Unicode category ("Lu"), script ("Han") or property ("White_Space").
+func lookupRuneClass(name string) (func(rune) bool, bool) {
+	if f, ok := runeClasses[name]; ok {
+		return f, true
+	}
+	for _, tables := range []map[string]*unicode.RangeTable{
+		unicode.Categories,
+		unicode.Scripts,
+		unicode.Properties,
+	} {
+		if tab, ok := tables[name]; ok {
+			return func(r rune) bool { return unicode.Is(tab, r) }, true
+		}
+	}
+	return nil, false
+}
+
+// checkRuneClass returns the character class named at position n.
+func checkRuneClass(L *lua.LState, n int) runePredicate {
+	name := L.CheckString(n)
+
+	f, ok := lookupRuneClass(name)
+	if !ok {
+		L.ArgError(n, "unknown character class '"+name+"'")
+	}
+	return func(r rune, _, _ int) bool { return f(r) }
+}
+
+// checkRunePredicate returns the predicate at position n, either a Lua
+// function called for every rune of s or the name of a character class
+// evaluated in Go.
+func checkRunePredicate(L *lua.LState, n int, name, s string) runePredicate {
+	if L.Get(n).Type() == lua.LTString {
+		return checkRuneClass(L, n)
+	}
+	return luaRunePredicate(L, name, L.CheckFunction(n), s)
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestRuneClasses(t *testing.T) {
+	tests := []struct {
+		class  string
+		s      string
+		goFunc func(rune) bool
+	}{
+		{"space", " \t hello 世界　", unicode.IsSpace},
+		{"letter", "123abc世界456", unicode.IsLetter},
+		{"digit", "abc123def٣", unicode.IsDigit},
+		{"punct", "!!hello, world!!", unicode.IsPunct},
+		{"upper", "ABCdefGHI", unicode.IsUpper},
+		{"lower", "abcDEFghi", unicode.IsLower},
+		{"control", "\x00\x01abc\x7f", unicode.IsControl},
+		{"Han", "abc你好def世界", func(r rune) bool { return unicode.Is(unicode.Han, r) }},
+		{"Greek", "abcαβγdef", func(r rune) bool { return unicode.Is(unicode.Greek, r) }},
+		{"Lu", "abcDEFghi", func(r rune) bool { return unicode.Is(unicode.Lu, r) }},
+		{"Nd", "x1y2z3", func(r rune) bool { return unicode.Is(unicode.Nd, r) }},
+		{"White_Space", "  a b  ", func(r rune) bool { return unicode.Is(unicode.White_Space, r) }},
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+	require.NoError(t, L.DoString(`strings = require("strings")`))
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/class=%s", i, tt.class), func(t *testing.T) {
+			call := func(name string) lua.LValue {
+				L.Push(L.GetField(L.GetGlobal("strings"), name))
+				L.Push(lua.LString(tt.s))
+				L.Push(lua.LString(tt.class))
+				L.Call(2, 1)
+				ret := L.Get(-1)
+				L.Pop(1)
+				return ret
+			}
+
+			require.Equal(t, lua.LBool(strings.ContainsFunc(tt.s, tt.goFunc)), call("ContainsFunc"))
+			require.Equal(t, lua.LNumber(strings.IndexFunc(tt.s, tt.goFunc)), call("IndexFunc"))
+			require.Equal(t, lua.LNumber(strings.LastIndexFunc(tt.s, tt.goFunc)), call("LastIndexFunc"))
+			require.Equal(t, lua.LString(strings.TrimFunc(tt.s, tt.goFunc)), call("TrimFunc"))
+			require.Equal(t, lua.LString(strings.TrimLeftFunc(tt.s, tt.goFunc)), call("TrimLeftFunc"))
+			require.Equal(t, lua.LString(strings.TrimRightFunc(tt.s, tt.goFunc)), call("TrimRightFunc"))
+
+			fields := call("FieldsFunc").(*lua.LTable)
+			got := make([]string, 0, fields.Len())
+			fields.ForEach(func(_, value lua.LValue) {
+				got = append(got, value.String())
+			})
+			require.Equal(t, strings.FieldsFunc(tt.s, tt.goFunc), got)
+
+			next := call("FieldsFuncSeq").(*lua.LFunction)
+			got = got[:0]
+			for {
+				L.Push(next)
+				L.Call(0, 1)
+				value := L.Get(-1)
+				L.Pop(1)
+				if value == lua.LNil {
+					break
+				}
+				got = append(got, value.String())
+			}
+			require.Equal(t, strings.FieldsFunc(tt.s, tt.goFunc), got)
+		})
+	}
+}
+
+func TestRuneClassErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		msg  string
+	}{
+		{`strings.IndexFunc("abc", "nosuchclass")`, "unknown character class 'nosuchclass'"},
+		{`strings.TrimFunc("abc", "Space")`, "unknown character class 'Space'"},
+		{`strings.FieldsFuncSeq("abc", "")`, "unknown character class ''"},
+		{`strings.FieldsFunc("abc", 1)`, "function expected"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(`local strings = require("strings")` + "\n" + tests[i].code)
+		require.Error(t, err, "case %d: %s", i, tests[i].code)
+		require.Contains(t, err.Error(), tests[i].msg, "case %d: %s", i, tests[i].code)
+
+		L.Close()
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// foldFuncs are the case-insensitive variants of the search functions.
+// They match rune by rune under Unicode simple case folding, like
+// EqualFold, and return byte offsets into s.
+var foldFuncs = map[string]lua.LGFunction{
+	"ContainsFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		substr := L.CheckString(2)
+
+		ret := indexFold(s, substr) >= 0
+		return helper.RetBool(L, ret)
+	},
+	"CountFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		substr := L.CheckString(2)
+
+		ret := countFold(s, substr)
+		return helper.RetInt(L, ret)
+	},
+	"HasPrefixFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		prefix := L.CheckString(2)
+
+		_, ret := prefixFold(s, prefix)
+		return helper.RetBool(L, ret)
+	},
+	"HasSuffixFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		suffix := L.CheckString(2)
+
+		_, ret := suffixFold(s, suffix)
+		return helper.RetBool(L, ret)
+	},
+	"IndexFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		substr := L.CheckString(2)
+
+		ret := indexFold(s, substr)
+		return helper.RetInt(L, ret)
+	},
+	"LastIndexFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		substr := L.CheckString(2)
+
+		ret := lastIndexFold(s, substr)
+		return helper.RetInt(L, ret)
+	},
+	"ReplaceFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+		z := L.CheckString(3)
+		n := L.CheckInt(4)
+
+		ret := replaceFold(s, t, z, n)
+		return helper.RetString(L, ret)
+	},
+	"TrimPrefixFold": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		prefix := L.CheckString(2)
+
+		ret := s
+		if n, ok := prefixFold(s, prefix); ok {
+			ret = s[n:]
+		}
+		return helper.RetString(L, ret)
+	},
+}
+
+// equalFoldRune reports whether a and b are equal under simple folding.
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixFold reports whether s begins with prefix, ignoring case, and
+// the length in bytes of the matching part of s.
+func prefixFold(s, prefix string) (int, bool) {
+	i := 0
+	for _, pr := range prefix {
+		if i >= len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !equalFoldRune(r, pr) {
+			return 0, false
+		}
+		i += size
+	}
+	return i, true
+}
+
+// suffixFold reports whether s ends with suffix, ignoring case, and the
+// length in bytes of the matching part of s.
+func suffixFold(s, suffix string) (int, bool) {
+	i := len(s)
+	for suffix != "" {
+		if i <= 0 {
+			return 0, false
+		}
+		sr, ssize := utf8.DecodeLastRuneInString(suffix)
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if !equalFoldRune(r, sr) {
+			return 0, false
+		}
+		suffix = suffix[:len(suffix)-ssize]
+		i -= size
+	}
+	return len(s) - i, true
+}
+
+// indexFold returns the byte offset of the first match of substr in s,
+// or -1.
+func indexFold(s, substr string) int {
+	for i := 0; i <= len(s); {
+		if _, ok := prefixFold(s[i:], substr); ok {
+			return i
+		}
+		if i == len(s) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return -1
+}
+
+// lastIndexFold returns the byte offset of the last match of substr in
+// s, or -1.
+func lastIndexFold(s, substr string) int {
+	for i := len(s); ; {
+		if _, ok := prefixFold(s[i:], substr); ok {
+			return i
+		}
+		if i == 0 {
+			return -1
+		}
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+}
+
+// countFold counts the non-overlapping matches of substr in s. Like
+// strings.Count, an empty substr matches around every rune.
+func countFold(s, substr string) int {
+	if substr == "" {
+		return utf8.RuneCountInString(s) + 1
+	}
+
+	count := 0
+	for i := 0; i < len(s); {
+		if n, ok := prefixFold(s[i:], substr); ok {
+			count++
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return count
+}
+
+// replaceFold is strings.Replace matching old case-insensitively.
+func replaceFold(s, old, new string, n int) string {
+	if old == "" || n == 0 {
+		return strings.Replace(s, old, new, n)
+	}
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(s) && n != 0; {
+		if m, ok := prefixFold(s[i:], old); ok {
+			b.WriteString(s[last:i])
+			b.WriteString(new)
+			i += m
+			last = i
+			n--
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestFoldFuncs(t *testing.T) {
+	const kelvin = "K" // folds to k, 3 bytes
+
+	tests := []struct {
+		name     string
+		args     []lua.LValue
+		expected lua.LValue
+	}{
+		{"ContainsFold", []lua.LValue{lua.LString("Hello World"), lua.LString("WORLD")}, lua.LTrue},
+		{"ContainsFold", []lua.LValue{lua.LString("Hello"), lua.LString("")}, lua.LTrue},
+		{"ContainsFold", []lua.LValue{lua.LString("Hello"), lua.LString("xyz")}, lua.LFalse},
+		{"ContainsFold", []lua.LValue{lua.LString("Straße"), lua.LString("STRASSE")}, lua.LFalse},
+		{"ContainsFold", []lua.LValue{lua.LString("ſtop"), lua.LString("STOP")}, lua.LTrue},
+
+		{"IndexFold", []lua.LValue{lua.LString("chicKen"), lua.LString("KEN")}, lua.LNumber(4)},
+		{"IndexFold", []lua.LValue{lua.LString("ab" + kelvin + "ey"), lua.LString("KEY")}, lua.LNumber(2)},
+		{"IndexFold", []lua.LValue{lua.LString("abc"), lua.LString("")}, lua.LNumber(0)},
+		{"IndexFold", []lua.LValue{lua.LString("abc"), lua.LString("D")}, lua.LNumber(-1)},
+		{"IndexFold", []lua.LValue{lua.LString("ΣΑΣ"), lua.LString("ας")}, lua.LNumber(2)},
+		{"LastIndexFold", []lua.LValue{lua.LString("Go gopher GO"), lua.LString("go")}, lua.LNumber(10)},
+		{"LastIndexFold", []lua.LValue{lua.LString("aaa"), lua.LString("AA")}, lua.LNumber(1)},
+		{"LastIndexFold", []lua.LValue{lua.LString("abc"), lua.LString("")}, lua.LNumber(3)},
+		{"LastIndexFold", []lua.LValue{lua.LString("abc"), lua.LString("x")}, lua.LNumber(-1)},
+		{"LastIndexFold", []lua.LValue{lua.LString("ΣσςΣx"), lua.LString("σ")}, lua.LNumber(6)},
+		{"LastIndexFold", []lua.LValue{lua.LString("\xe4\xbdK"), lua.LString("k")}, lua.LNumber(2)},
+
+		{"HasPrefixFold", []lua.LValue{lua.LString("Gopher"), lua.LString("GO")}, lua.LTrue},
+		{"HasPrefixFold", []lua.LValue{lua.LString("Go"), lua.LString("Gopher")}, lua.LFalse},
+		{"HasPrefixFold", []lua.LValue{lua.LString(kelvin + "ey"), lua.LString("k")}, lua.LTrue},
+		{"HasSuffixFold", []lua.LValue{lua.LString("Amigo"), lua.LString("IGO")}, lua.LTrue},
+		{"HasSuffixFold", []lua.LValue{lua.LString("go"), lua.LString("amigo")}, lua.LFalse},
+		{"HasSuffixFold", []lua.LValue{lua.LString("ma" + kelvin), lua.LString("K")}, lua.LTrue},
+
+		{"CountFold", []lua.LValue{lua.LString("Cheese CHEESE"), lua.LString("e")}, lua.LNumber(6)},
+		{"CountFold", []lua.LValue{lua.LString("aAaA"), lua.LString("aa")}, lua.LNumber(2)},
+		{"CountFold", []lua.LValue{lua.LString("five"), lua.LString("")}, lua.LNumber(5)},
+
+		{"TrimPrefixFold", []lua.LValue{lua.LString("Content-Type"), lua.LString("content-")}, lua.LString("Type")},
+		{"TrimPrefixFold", []lua.LValue{lua.LString(kelvin + "elvin"), lua.LString("K")}, lua.LString("elvin")},
+		{"TrimPrefixFold", []lua.LValue{lua.LString("Type"), lua.LString("x")}, lua.LString("Type")},
+
+		{"ReplaceFold", []lua.LValue{lua.LString("oink OINK Oink"), lua.LString("oink"), lua.LString("moo"), lua.LNumber(-1)}, lua.LString("moo moo moo")},
+		{"ReplaceFold", []lua.LValue{lua.LString("oink OINK Oink"), lua.LString("OINK"), lua.LString("moo"), lua.LNumber(2)}, lua.LString("moo moo Oink")},
+		{"ReplaceFold", []lua.LValue{lua.LString("a" + kelvin + "b"), lua.LString("k"), lua.LString("-"), lua.LNumber(-1)}, lua.LString("a-b")},
+		{"ReplaceFold", []lua.LValue{lua.LString("abc"), lua.LString(""), lua.LString("-"), lua.LNumber(-1)}, lua.LString("-a-b-c-")},
+		{"ReplaceFold", []lua.LValue{lua.LString("abc"), lua.LString("x"), lua.LString("-"), lua.LNumber(-1)}, lua.LString("abc")},
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+	require.NoError(t, L.DoString(`strings = require("strings")`))
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/func=%s", i, tt.name), func(t *testing.T) {
+			L.Push(L.GetField(L.GetGlobal("strings"), tt.name))
+			for _, arg := range tt.args {
+				L.Push(arg)
+			}
+			L.Call(len(tt.args), 1)
+			got := L.Get(-1)
+			L.Pop(1)
+
+			require.Equal(t, tt.expected, got,
+				"case %d: Lua returned %v but expected %v (args: %v)",
+				i, got, tt.expected, tt.args)
+		})
+	}
+}
+
+func TestFoldLuaIndex(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		LuaIndex: true,
+	})
+
+	err := L.DoString(`
+		local strings = require("strings")
+		local s = "Key=VALUE"
+		local i = strings.IndexFold(s, "value")
+		assert(i == 5 and s:sub(i) == "VALUE")
+		assert(strings.LastIndexFold(s, "E") == 9)
+		assert(strings.IndexFold(s, "#") == nil)
+	`)
+	require.NoError(t, err)
+}
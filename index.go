@@ -0,0 +1,40 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	lua "github.com/yuin/gopher-lua"
+)
+
+// indexFuncs are the functions returning a 0-based byte offset or -1.
+var indexFuncs = map[string]bool{
+	"Index":         true,
+	"IndexAny":      true,
+	"IndexByte":     true,
+	"IndexFold":     true,
+	"IndexFunc":     true,
+	"IndexRune":     true,
+	"LastIndex":     true,
+	"LastIndexAny":  true,
+	"LastIndexByte": true,
+	"LastIndexFold": true,
+	"LastIndexFunc": true,
+}
+
+// luaIndex converts the result of fn to a Lua position: 1-based, and nil
+// when not found.
+func luaIndex(fn lua.LGFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		n := fn(L)
+		if i, ok := L.Get(-1).(lua.LNumber); ok {
+			if i < 0 {
+				L.Replace(-1, lua.LNil)
+			} else {
+				L.Replace(-1, i+1)
+			}
+		}
+		return n
+	}
+}
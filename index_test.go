@@ -0,0 +1,116 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestLuaIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		arg    lua.LValue
+		goFunc func(s string) int
+	}{
+		{"Index", "chicken", lua.LString("ken"), func(s string) int { return strings.Index(s, "ken") }},
+		{"Index", "chicken", lua.LString("dmr"), func(s string) int { return strings.Index(s, "dmr") }},
+		{"Index", "你好世界", lua.LString("世界"), func(s string) int { return strings.Index(s, "世界") }},
+		{"Index", "abc", lua.LString(""), func(s string) int { return strings.Index(s, "") }},
+		{"IndexAny", "golang", lua.LString("ny"), func(s string) int { return strings.IndexAny(s, "ny") }},
+		{"IndexAny", "golang", lua.LString("xyz"), func(s string) int { return strings.IndexAny(s, "xyz") }},
+		{"IndexByte", "golang", lua.LNumber('l'), func(s string) int { return strings.IndexByte(s, 'l') }},
+		{"IndexByte", "golang", lua.LNumber('x'), func(s string) int { return strings.IndexByte(s, 'x') }},
+		{"IndexRune", "chicken", lua.LNumber('k'), func(s string) int { return strings.IndexRune(s, 'k') }},
+		{"IndexRune", "chicken", lua.LNumber('d'), func(s string) int { return strings.IndexRune(s, 'd') }},
+		{"IndexFunc", "Hello, 世界", lua.LString("Han"), func(s string) int {
+			return strings.IndexFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) })
+		}},
+		{"IndexFunc", "Hello", lua.LString("Han"), func(s string) int {
+			return strings.IndexFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) })
+		}},
+		{"LastIndex", "go gopher", lua.LString("go"), func(s string) int { return strings.LastIndex(s, "go") }},
+		{"LastIndex", "go gopher", lua.LString("rodent"), func(s string) int { return strings.LastIndex(s, "rodent") }},
+		{"LastIndexAny", "go gopher", lua.LString("go"), func(s string) int { return strings.LastIndexAny(s, "go") }},
+		{"LastIndexAny", "go gopher", lua.LString("fail"), func(s string) int { return strings.LastIndexAny(s, "fail") }},
+		{"LastIndexByte", "Hello, world", lua.LNumber('o'), func(s string) int { return strings.LastIndexByte(s, 'o') }},
+		{"LastIndexByte", "Hello, world", lua.LNumber('x'), func(s string) int { return strings.LastIndexByte(s, 'x') }},
+		{"LastIndexFunc", "go 123", lua.LString("digit"), func(s string) int {
+			return strings.LastIndexFunc(s, unicode.IsDigit)
+		}},
+		{"LastIndexFunc", "go", lua.LString("digit"), func(s string) int {
+			return strings.LastIndexFunc(s, unicode.IsDigit)
+		}},
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		LuaIndex: true,
+	})
+	require.NoError(t, L.DoString(`strings = require("strings")`))
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/func=%s", i, tt.name), func(t *testing.T) {
+			var expected lua.LValue = lua.LNil
+			if i := tt.goFunc(tt.s); i >= 0 {
+				expected = lua.LNumber(i + 1)
+			}
+
+			L.Push(L.GetField(L.GetGlobal("strings"), tt.name))
+			L.Push(lua.LString(tt.s))
+			L.Push(tt.arg)
+			L.Call(2, 1)
+			got := L.Get(-1)
+			L.Pop(1)
+
+			require.Equal(t, expected, got,
+				"case %d: Lua returned %v but expected %v (string: %q, arg: %v)",
+				i, got, expected, tt.s, tt.arg)
+		})
+	}
+
+	// positions plug into string.sub
+	err := L.DoString(`
+		local s = "key=value"
+		local i = strings.Index(s, "=")
+		assert(s:sub(1, i - 1) == "key" and s:sub(i + 1) == "value")
+		assert(strings.Index(s, "#") == nil)
+	`)
+	require.NoError(t, err)
+}
+
+func TestLuaIndexDefault(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		StringMethods: true,
+		LuaIndex:      true,
+	})
+
+	// the string methods use the configured module, Loader keeps 0-based
+	L.PreloadModule("strings0", lua_strings.Loader)
+
+	err := L.DoString(`
+		local strings = require("strings")
+		local strings0 = require("strings0")
+
+		assert(("abc"):Index("c") == 3)
+		assert(("abc"):LastIndexFunc("letter") == 3)
+		assert(strings0.Index("abc", "c") == 2)
+		assert(strings0.Index("abc", "x") == -1)
+	`)
+	require.NoError(t, err)
+}
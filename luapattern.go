@@ -0,0 +1,552 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A luaPattern is a compiled Lua 5.1 pattern. Patterns without %b, %f
+// and back-references are regular and run as a Go regexp; the others
+// run on a backtracking matcher like the one of the Lua string library.
+//
+// Lua patterns match bytes, the regexp matches the runes \x{0}-\x{ff}
+// standing for them, see latin1Reader.
+type luaPattern struct {
+	items    []patternItem
+	anchor   bool
+	position []bool // position captures, by capture index
+	re       *regexp.Regexp
+}
+
+type patternItemKind int
+
+const (
+	itemClass    patternItemKind = iota // one byte of set, repeated by rep
+	itemOpen                            // start of capture n
+	itemPosition                        // position capture n
+	itemClose                           // end of capture n
+	itemBalance                         // %bxy
+	itemFrontier                        // %f[set]
+	itemBackref                         // %1-%9, capture n
+	itemEnd                             // $ at the end
+)
+
+type patternItem struct {
+	kind patternItemKind
+	set  byteSet
+	rep  byte // itemClass: 0, '?', '*', '+' or '-'
+	b, e byte // itemBalance
+	n    int
+}
+
+// luaPatternMaxCaptures is LUA_MAXCAPTURES.
+const luaPatternMaxCaptures = 32
+
+// luaPatternSpecials are the bytes that make a pattern more than a plain
+// string for Find.
+const luaPatternSpecials = "^$*+?.([%-"
+
+var luaPatternCache = newCompileCache[string, *luaPattern](256)
+
+// compileLuaPattern compiles pattern, or returns the pattern compiled
+// before.
+func compileLuaPattern(pattern string) (*luaPattern, error) {
+	return luaPatternCache.get(pattern, func() (*luaPattern, error) {
+		lp, err := parseLuaPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if lp.regular() {
+			// the syntax is built from the items, and valid
+			lp.re, _ = regexp.Compile(lp.regexpSyntax())
+		}
+		return lp, nil
+	})
+}
+
+func parseLuaPattern(p string) (*luaPattern, error) {
+	lp := &luaPattern{}
+	if strings.HasPrefix(p, "^") {
+		lp.anchor = true
+		p = p[1:]
+	}
+
+	var open []int // unclosed captures
+	for i := 0; i < len(p); {
+		c := p[i]
+		switch {
+		case c == '(':
+			n := len(lp.position)
+			if n >= luaPatternMaxCaptures {
+				return nil, errors.New("too many captures")
+			}
+			if i+1 < len(p) && p[i+1] == ')' {
+				lp.items = append(lp.items, patternItem{kind: itemPosition, n: n})
+				lp.position = append(lp.position, true)
+				i += 2
+				continue
+			}
+			lp.items = append(lp.items, patternItem{kind: itemOpen, n: n})
+			lp.position = append(lp.position, false)
+			open = append(open, n)
+			i++
+		case c == ')':
+			if len(open) == 0 {
+				return nil, errors.New("invalid pattern capture")
+			}
+			lp.items = append(lp.items, patternItem{kind: itemClose, n: open[len(open)-1]})
+			open = open[:len(open)-1]
+			i++
+		case c == '$' && i == len(p)-1:
+			lp.items = append(lp.items, patternItem{kind: itemEnd})
+			i++
+		case c == '%' && i+1 < len(p) && p[i+1] == 'b':
+			if i+3 >= len(p) {
+				return nil, errors.New("malformed pattern (missing arguments to '%b')")
+			}
+			lp.items = append(lp.items, patternItem{kind: itemBalance, b: p[i+2], e: p[i+3]})
+			i += 4
+		case c == '%' && i+1 < len(p) && p[i+1] == 'f':
+			i += 2
+			if i >= len(p) || p[i] != '[' {
+				return nil, errors.New("missing '[' after '%f' in pattern")
+			}
+			set, end, err := parseLuaPatternClass(p, i)
+			if err != nil {
+				return nil, err
+			}
+			lp.items = append(lp.items, patternItem{kind: itemFrontier, set: set})
+			i = end
+		case c == '%' && i+1 < len(p) && isDigit(p[i+1]):
+			n := int(p[i+1] - '1')
+			if n < 0 || n >= len(lp.position) || isOpenCapture(open, n) {
+				return nil, fmt.Errorf("invalid capture index %%%c", p[i+1])
+			}
+			lp.items = append(lp.items, patternItem{kind: itemBackref, n: n})
+			i += 2
+		default:
+			set, end, err := parseLuaPatternSingle(p, i)
+			if err != nil {
+				return nil, err
+			}
+			item := patternItem{kind: itemClass, set: set}
+			if end < len(p) && strings.IndexByte("?*+-", p[end]) >= 0 {
+				item.rep = p[end]
+				end++
+			}
+			lp.items = append(lp.items, item)
+			i = end
+		}
+	}
+	if len(open) > 0 {
+		return nil, errors.New("unfinished capture")
+	}
+	return lp, nil
+}
+
+func isOpenCapture(open []int, n int) bool {
+	for _, i := range open {
+		if i == n {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLuaPatternSingle parses the single character class at p[i], and
+// returns the index after it.
+func parseLuaPatternSingle(p string, i int) (byteSet, int, error) {
+	var set byteSet
+	switch p[i] {
+	case '.':
+		set.invert()
+		return set, i + 1, nil
+	case '%':
+		if i+1 >= len(p) {
+			return set, 0, errors.New("malformed pattern (ends with '%')")
+		}
+		return luaClassSet(p[i+1]), i + 2, nil
+	case '[':
+		return parseLuaPatternClass(p, i)
+	}
+	set.add(p[i])
+	return set, i + 1, nil
+}
+
+// parseLuaPatternClass parses the set [...] at p[i], and returns the
+// index after it. A ']' right after '[' or '[^' belongs to the set.
+func parseLuaPatternClass(p string, i int) (byteSet, int, error) {
+	var set byteSet
+
+	first := i + 1
+	negate := first < len(p) && p[first] == '^'
+	if negate {
+		first++
+	}
+
+	// find the closing ']'
+	end := first
+	for {
+		if end >= len(p) {
+			return set, 0, errors.New("malformed pattern (missing ']')")
+		}
+		c := p[end]
+		end++
+		if c == '%' && end < len(p) {
+			end++
+		}
+		if end < len(p) && p[end] == ']' {
+			break
+		}
+	}
+
+	for j := first; j < end; j++ {
+		switch {
+		case p[j] == '%':
+			j++
+			set.union(luaClassSet(p[j]))
+		case j+2 < end && p[j+1] == '-':
+			set.addRange(p[j], p[j+2])
+			j += 2
+		default:
+			set.add(p[j])
+		}
+	}
+	if negate {
+		set.invert()
+	}
+	return set, end + 1, nil
+}
+
+// luaClassSet returns the set of %cl, in the C locale.
+func luaClassSet(cl byte) byteSet {
+	var set byteSet
+	for c := 0; c < 256; c++ {
+		if matchLuaClass(byte(c), cl) {
+			set.add(byte(c))
+		}
+	}
+	return set
+}
+
+func matchLuaClass(c, cl byte) bool {
+	var res bool
+	switch cl | 0x20 {
+	case 'a':
+		res = isAlpha(c)
+	case 'c':
+		res = c < 0x20 || c == 0x7f
+	case 'd':
+		res = isDigit(c)
+	case 'l':
+		res = 'a' <= c && c <= 'z'
+	case 'p':
+		res = '!' <= c && c <= '~' && !isAlpha(c) && !isDigit(c)
+	case 's':
+		res = c == ' ' || '\t' <= c && c <= '\r'
+	case 'u':
+		res = 'A' <= c && c <= 'Z'
+	case 'w':
+		res = isAlpha(c) || isDigit(c)
+	case 'x':
+		res = isDigit(c) || 'a' <= c|0x20 && c|0x20 <= 'f'
+	case 'z':
+		res = c == 0
+	default:
+		return cl == c
+	}
+	if 'A' <= cl && cl <= 'Z' {
+		return !res
+	}
+	return res
+}
+
+func isAlpha(c byte) bool { return 'a' <= c|0x20 && c|0x20 <= 'z' }
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// regular reports whether lp can run as a regexp.
+func (lp *luaPattern) regular() bool {
+	for _, item := range lp.items {
+		switch item.kind {
+		case itemBalance, itemFrontier, itemBackref:
+			return false
+		}
+	}
+	return true
+}
+
+// regexpSyntax translates lp to a regexp over the runes \x{0}-\x{ff}.
+func (lp *luaPattern) regexpSyntax() string {
+	var b strings.Builder
+	if lp.anchor {
+		b.WriteString(`\A`)
+	}
+	for _, item := range lp.items {
+		switch item.kind {
+		case itemClass:
+			item.set.writeRegexp(&b)
+			switch item.rep {
+			case '?', '*', '+':
+				b.WriteByte(item.rep)
+			case '-':
+				b.WriteString("*?")
+			}
+		case itemOpen:
+			b.WriteByte('(')
+		case itemPosition:
+			b.WriteString("()")
+		case itemClose:
+			b.WriteByte(')')
+		case itemEnd:
+			b.WriteString(`\z`)
+		}
+	}
+	return b.String()
+}
+
+// find returns the leftmost match of lp in s at or after init, as the
+// start and end of the match followed by those of the captures, or nil.
+// A position capture is empty, at its position. ascii tells whether s is
+// ASCII, which callers decide once per subject rather than once a match.
+func (lp *luaPattern) find(s string, init int, ascii bool) []int {
+	if lp.re != nil {
+		var loc []int
+		if ascii {
+			loc = lp.re.FindStringSubmatchIndex(s[init:])
+		} else {
+			loc = lp.re.FindReaderSubmatchIndex(&latin1Reader{s: s[init:]})
+		}
+		for i := range loc {
+			loc[i] += init
+		}
+		return loc
+	}
+
+	m := &patternMatcher{lp: lp, s: s, caps: make([]int, 2+2*len(lp.position))}
+	for start := init; start <= len(s); start++ {
+		if end := m.match(start, 0); end >= 0 {
+			m.caps[0], m.caps[1] = start, end
+			return m.caps
+		}
+		if lp.anchor {
+			break
+		}
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// latin1Reader reads every byte of s as the rune of the same value.
+type latin1Reader struct {
+	s string
+	i int
+}
+
+func (r *latin1Reader) ReadRune() (rune, int, error) {
+	if r.i >= len(r.s) {
+		return 0, 0, io.EOF
+	}
+	c := r.s[r.i]
+	r.i++
+	return rune(c), 1, nil
+}
+
+// patternMatcher is the backtracking matcher of lstrlib.c. The
+// recursion depth is bounded by the number of items.
+type patternMatcher struct {
+	lp   *luaPattern
+	s    string
+	caps []int
+}
+
+// match matches the items from i at s[si:], and returns the end of the
+// match or -1.
+func (m *patternMatcher) match(si, i int) int {
+	s, items := m.s, m.lp.items
+	for i < len(items) {
+		item := &items[i]
+		switch item.kind {
+		case itemOpen:
+			m.caps[2+2*item.n] = si
+			return m.match(si, i+1)
+		case itemPosition:
+			m.caps[2+2*item.n] = si
+			m.caps[3+2*item.n] = si
+		case itemClose:
+			m.caps[3+2*item.n] = si
+		case itemEnd:
+			if si != len(s) {
+				return -1
+			}
+		case itemBalance:
+			if si = m.matchBalance(si, item.b, item.e); si < 0 {
+				return -1
+			}
+		case itemFrontier:
+			var prev, cur byte
+			if si > 0 {
+				prev = s[si-1]
+			}
+			if si < len(s) {
+				cur = s[si]
+			}
+			if item.set.has(prev) || !item.set.has(cur) {
+				return -1
+			}
+		case itemBackref:
+			if m.lp.position[item.n] {
+				return -1
+			}
+			capture := s[m.caps[2+2*item.n]:m.caps[3+2*item.n]]
+			if !strings.HasPrefix(s[si:], capture) {
+				return -1
+			}
+			si += len(capture)
+		case itemClass:
+			ok := si < len(s) && item.set.has(s[si])
+			switch item.rep {
+			case '?':
+				if ok {
+					if end := m.match(si+1, i+1); end >= 0 {
+						return end
+					}
+				}
+			case '*':
+				return m.maxExpand(si, i)
+			case '+':
+				if !ok {
+					return -1
+				}
+				return m.maxExpand(si+1, i)
+			case '-':
+				return m.minExpand(si, i)
+			default:
+				if !ok {
+					return -1
+				}
+				si++
+			}
+		}
+		i++
+	}
+	return si
+}
+
+func (m *patternMatcher) maxExpand(si, i int) int {
+	set := &m.lp.items[i].set
+	n := 0
+	for si+n < len(m.s) && set.has(m.s[si+n]) {
+		n++
+	}
+	for ; n >= 0; n-- {
+		if end := m.match(si+n, i+1); end >= 0 {
+			return end
+		}
+	}
+	return -1
+}
+
+func (m *patternMatcher) minExpand(si, i int) int {
+	set := &m.lp.items[i].set
+	for {
+		if end := m.match(si, i+1); end >= 0 {
+			return end
+		}
+		if si >= len(m.s) || !set.has(m.s[si]) {
+			return -1
+		}
+		si++
+	}
+}
+
+func (m *patternMatcher) matchBalance(si int, b, e byte) int {
+	if si >= len(m.s) || m.s[si] != b {
+		return -1
+	}
+	depth := 1
+	for i := si + 1; i < len(m.s); i++ {
+		switch m.s[i] {
+		case e:
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		case b:
+			depth++
+		}
+	}
+	return -1
+}
+
+// byteSet is a set of bytes.
+type byteSet [4]uint64
+
+func (bs *byteSet) has(c byte) bool { return bs[c>>6]&(1<<(c&63)) != 0 }
+
+func (bs *byteSet) add(c byte) { bs[c>>6] |= 1 << (c & 63) }
+
+func (bs *byteSet) addRange(lo, hi byte) {
+	for c := int(lo); c <= int(hi); c++ {
+		bs.add(byte(c))
+	}
+}
+
+func (bs *byteSet) union(other byteSet) {
+	for i := range bs {
+		bs[i] |= other[i]
+	}
+}
+
+func (bs *byteSet) invert() {
+	for i := range bs {
+		bs[i] = ^bs[i]
+	}
+}
+
+// writeRegexp writes bs as a regexp character class.
+func (bs *byteSet) writeRegexp(b *strings.Builder) {
+	var ranges [][2]int
+	for c := 0; c < 256; c++ {
+		if !bs.has(byte(c)) {
+			continue
+		}
+		if n := len(ranges); n > 0 && ranges[n-1][1] == c-1 {
+			ranges[n-1][1] = c
+		} else {
+			ranges = append(ranges, [2]int{c, c})
+		}
+	}
+
+	switch {
+	case len(ranges) == 0:
+		b.WriteString(`[^\x{0}-\x{10ffff}]`)
+		return
+	case len(ranges) == 1 && ranges[0][0] == ranges[0][1]:
+		fmt.Fprintf(b, `\x{%x}`, ranges[0][0])
+		return
+	}
+	b.WriteByte('[')
+	for _, r := range ranges {
+		if r[0] == r[1] {
+			fmt.Fprintf(b, `\x{%x}`, r[0])
+		} else {
+			fmt.Fprintf(b, `\x{%x}-\x{%x}`, r[0], r[1])
+		}
+	}
+	b.WriteByte(']')
+}
@@ -0,0 +1,231 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"unicode/utf8"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaUTF8CharPattern matches exactly one UTF-8 byte sequence.
+const luaUTF8CharPattern = "[\x00-\x7F\xC2-\xF4][\x80-\xBF]*"
+
+// LuaUTF8Loader loads the utf8 library of Lua 5.3, for scripts written
+// for stock Lua. Byte positions are 1-based and may be negative.
+func LuaUTF8Loader(L *lua.LState) int {
+	mod := L.NewTable()
+	L.SetFuncs(mod, luaUTF8Funcs)
+	L.SetField(mod, "charpattern", lua.LString(luaUTF8CharPattern))
+	L.Push(mod)
+	return 1
+}
+
+// OpenLuaUTF8 installs the utf8 library of Lua 5.3 as the global utf8,
+// the way lua.OpenString installs the string library.
+func OpenLuaUTF8(L *lua.LState) int {
+	mod := L.RegisterModule("utf8", luaUTF8Funcs).(*lua.LTable)
+	L.SetField(mod, "charpattern", lua.LString(luaUTF8CharPattern))
+	L.Push(mod)
+	return 1
+}
+
+// luaUTF8Decode decodes the byte sequence at the start of s like
+// utf8_decode of Lua 5.3, which unlike Go accepts the surrogate halves.
+func luaUTF8Decode(s string) (r rune, size int, ok bool) {
+	r, size = utf8.DecodeRuneInString(s)
+	if r != utf8.RuneError || size > 1 {
+		return r, size, true
+	}
+	if len(s) >= 3 && s[0] == 0xED && s[1]&0xE0 == 0xA0 && s[2]&0xC0 == 0x80 {
+		return 0xD000 | rune(s[1]&0x3F)<<6 | rune(s[2]&0x3F), 3, true
+	}
+	return 0, 0, false
+}
+
+// luaUTF8Encode encodes r like the "%U" format of Lua 5.3.
+func luaUTF8Encode(b []byte, r rune) []byte {
+	if 0xD800 <= r && r <= 0xDFFF {
+		return append(b, byte(0xE0|r>>12), byte(0x80|r>>6&0x3F), byte(0x80|r&0x3F))
+	}
+	return utf8.AppendRune(b, r)
+}
+
+// isCont reports whether s[i] is a continuation byte.
+func isCont(s string, i int) bool {
+	return i < len(s) && !utf8.RuneStart(s[i])
+}
+
+// luaPosRelat converts a negative position to a position from the start,
+// like u_posrelat of Lua 5.3.
+func luaPosRelat(pos, n int) int {
+	if pos >= 0 {
+		return pos
+	}
+	if -pos > n {
+		return 0
+	}
+	return n + pos + 1
+}
+
+// luaCheckInteger returns the integer at position n like luaL_checkinteger
+// of Lua 5.3, which unlike L.CheckInt rejects numbers with a fraction.
+func luaCheckInteger(L *lua.LState, n int) int {
+	v := L.CheckNumber(n)
+	if v != lua.LNumber(int(v)) {
+		L.ArgError(n, "number has no integer representation")
+	}
+	return int(v)
+}
+
+// luaOptInteger is luaCheckInteger with d for a missing argument.
+func luaOptInteger(L *lua.LState, n int, d int) int {
+	if L.Get(n) == lua.LNil {
+		return d
+	}
+	return luaCheckInteger(L, n)
+}
+
+var luaUTF8Funcs = map[string]lua.LGFunction{
+	"char": func(L *lua.LState) int {
+		var b []byte
+		for i := 1; i <= L.GetTop(); i++ {
+			r := luaCheckInteger(L, i)
+
+			if r < 0 || r > utf8.MaxRune {
+				L.ArgError(i, "value out of range")
+			}
+			b = luaUTF8Encode(b, rune(r))
+		}
+		return helper.RetString(L, string(b))
+	},
+	"codepoint": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		i := luaPosRelat(luaOptInteger(L, 2, 1), len(s))
+		j := luaPosRelat(luaOptInteger(L, 3, i), len(s))
+
+		if i < 1 {
+			L.ArgError(2, "out of range")
+		}
+		if j > len(s) {
+			L.ArgError(3, "out of range")
+		}
+
+		n := 0
+		for p := i - 1; p < j; n++ {
+			r, size, ok := luaUTF8Decode(s[p:])
+			if !ok {
+				L.RaiseError("invalid UTF-8 code")
+			}
+			L.Push(lua.LNumber(r))
+			p += size
+		}
+		return n
+	},
+	"codes": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		L.Push(L.NewFunction(luaUTF8CodesIter))
+		L.Push(lua.LString(s))
+		L.Push(lua.LNumber(0))
+		return 3
+	},
+	"len": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		i := luaPosRelat(luaOptInteger(L, 2, 1), len(s))
+		j := luaPosRelat(luaOptInteger(L, 3, -1), len(s))
+
+		if i < 1 || i-1 > len(s) {
+			L.ArgError(2, "initial position out of string")
+		}
+		if j-1 >= len(s) {
+			L.ArgError(3, "final position out of string")
+		}
+
+		n := 0
+		for p := i - 1; p < j; n++ {
+			_, size, ok := luaUTF8Decode(s[p:])
+			if !ok {
+				L.Push(lua.LNil)
+				L.Push(lua.LNumber(p + 1))
+				return 2
+			}
+			p += size
+		}
+		return helper.RetInt(L, n)
+	},
+	"offset": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		n := luaCheckInteger(L, 2)
+		i := 1
+		if n < 0 {
+			i = len(s) + 1
+		}
+		i = luaPosRelat(luaOptInteger(L, 3, i), len(s))
+
+		if i < 1 || i-1 > len(s) {
+			L.ArgError(3, "position out of range")
+		}
+
+		p := i - 1
+		if n == 0 {
+			// find the beginning of the current byte sequence
+			for p > 0 && isCont(s, p) {
+				p--
+			}
+		} else {
+			if isCont(s, p) {
+				L.RaiseError("initial position is a continuation byte")
+			}
+			if n < 0 {
+				for ; n < 0 && p > 0; n++ {
+					p--
+					for p > 0 && isCont(s, p) {
+						p--
+					}
+				}
+			} else {
+				for n--; n > 0 && p < len(s); n-- {
+					p++
+					for isCont(s, p) {
+						p++
+					}
+				}
+			}
+		}
+
+		if n != 0 {
+			L.Push(lua.LNil)
+			return 1
+		}
+		return helper.RetInt(L, p+1)
+	},
+}
+
+// luaUTF8CodesIter is the iterator function of utf8.codes.
+func luaUTF8CodesIter(L *lua.LState) int {
+	s := L.CheckString(1)
+	p := L.CheckInt(2) - 1
+
+	if p < 0 {
+		p = 0
+	} else if p < len(s) {
+		// skip the current byte sequence
+		p++
+		for isCont(s, p) {
+			p++
+		}
+	}
+	if p >= len(s) {
+		return 0
+	}
+
+	r, size, ok := luaUTF8Decode(s[p:])
+	if !ok || isCont(s, p+size) {
+		L.RaiseError("invalid UTF-8 code")
+	}
+	return helper.Return(L, p+1, int(r))
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestLuaUTF8(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.Push(L.NewFunction(lua_strings.OpenLuaUTF8))
+	L.Call(0, 0)
+
+	// adapted from utf8.lua of the Lua 5.3 test suite
+	err := L.DoString(`
+		assert(utf8 == package.loaded.utf8)
+
+		local function checkerror(msg, f, ...)
+			local ok, err = pcall(f, ...)
+			assert(not ok and string.find(err, msg, 1, true), err)
+		end
+
+		-- char
+		assert(utf8.char() == "")
+		assert(utf8.char(72, 0x4E16, 0x754C, 0x1F600) == "H世界😀")
+		assert(utf8.char(0x10FFFF) == "\244\143\191\191")
+		assert(utf8.char(0xD800) == "\237\160\128")
+		checkerror("value out of range", utf8.char, 0x110000)
+		checkerror("value out of range", utf8.char, 65, -1)
+		checkerror("number has no integer representation", utf8.char, 65.5)
+		assert(utf8.char(65.0) == "A")
+
+		-- charpattern
+		local t = {}
+		for c in string.gmatch("aé世😀", utf8.charpattern) do
+			t[#t+1] = c
+		end
+		assert(#t == 4 and t[3] == "世")
+
+		-- codes
+		local s = "a世😀"
+		local ps, cs = {}, {}
+		for p, c in utf8.codes(s) do
+			ps[#ps+1] = p
+			cs[#cs+1] = c
+		end
+		assert(table.concat(ps, ",") == "1,2,5")
+		assert(table.concat(cs, ",") == "97,19990,128512")
+		for _ in utf8.codes("") do error("empty string has no codes") end
+		checkerror("invalid UTF-8 code", function()
+			for _ in utf8.codes("ab\255") do end
+		end)
+		checkerror("invalid UTF-8 code", function()
+			for _ in utf8.codes("\228\184\150\128") do end
+		end)
+
+		-- codepoint
+		assert(utf8.codepoint("abc") == 97)
+		local a, b, c = utf8.codepoint("a世b", 1, -1)
+		assert(a == 97 and b == 0x4E16 and c == 98)
+		assert(select("#", utf8.codepoint("abc", 3, 2)) == 0)
+		assert(utf8.codepoint("a世b", 2) == 0x4E16)
+		assert(utf8.codepoint("a世b", -1) == 98)
+		assert(utf8.codepoint("\237\160\128") == 0xD800)
+		checkerror("out of range", utf8.codepoint, "abc", 0)
+		checkerror("out of range", utf8.codepoint, "abc", 1, 4)
+		checkerror("number has no integer representation", utf8.codepoint, "abc", 1.5)
+		checkerror("invalid UTF-8 code", utf8.codepoint, "a世b", 3)
+		checkerror("invalid UTF-8 code", utf8.codepoint, "\244\144\128\128")
+		checkerror("invalid UTF-8 code", utf8.codepoint, "\192\128")
+
+		-- len
+		assert(utf8.len("") == 0)
+		assert(utf8.len("a世😀") == 3)
+		assert(utf8.len("a世😀", 2) == 2)
+		assert(utf8.len("a世😀", -4) == 1)
+		assert(utf8.len("a世😀", 1, 1) == 1)
+		assert(utf8.len("abc", 4) == 0)
+		assert(utf8.len("abc", 3, 2) == 0)
+		local n, p = utf8.len("ab\255c")
+		assert(n == nil and p == 3)
+		local n, p = utf8.len("a世b", 3)
+		assert(n == nil and p == 3)
+		checkerror("initial position out of string", utf8.len, "abc", 5)
+		checkerror("initial position out of string", utf8.len, "abc", 0)
+		checkerror("final position out of string", utf8.len, "abc", 1, 4)
+		checkerror("number has no integer representation", utf8.len, "abc", 1, 2.5)
+
+		-- offset
+		local s = "a世😀b"
+		assert(utf8.offset(s, 1) == 1)
+		assert(utf8.offset(s, 2) == 2)
+		assert(utf8.offset(s, 3) == 5)
+		assert(utf8.offset(s, 4) == 9)
+		assert(utf8.offset(s, 5) == 10)
+		assert(utf8.offset(s, 6) == nil)
+		assert(utf8.offset(s, -1) == 9)
+		assert(utf8.offset(s, -4) == 1)
+		assert(utf8.offset(s, -5) == nil)
+		assert(utf8.offset(s, 0, 3) == 2)
+		assert(utf8.offset(s, 0, 8) == 5)
+		assert(utf8.offset(s, 2, 2) == 5)
+		assert(utf8.offset("", 1) == 1)
+		assert(utf8.offset("", -1) == nil)
+		checkerror("position out of range", utf8.offset, "abc", 1, 5)
+		checkerror("position out of range", utf8.offset, "abc", 1, -4)
+		checkerror("number has no integer representation", utf8.offset, "abc", 0.5)
+		checkerror("continuation byte", utf8.offset, s, 1, 3)
+	`)
+	require.NoError(t, err)
+}
+
+func TestLuaUTF8Loader(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("utf8", lua_strings.LuaUTF8Loader)
+
+	err := L.DoString(`
+		local utf8 = require("utf8")
+		assert(utf8.len("你好") == 2)
+		assert(utf8.charpattern == "[\0-\127\194-\244][\128-\191]*")
+	`)
+	require.NoError(t, err)
+}
@@ -0,0 +1,424 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaMatcherTypeName = "strings.Matcher"
+
+// Matcher finds a set of strings in one pass over the text, with an
+// Aho-Corasick automaton. It is immutable once built, so one Matcher
+// may be used by many LStates at the same time, see PushMatcher.
+//
+// Matches are leftmost-longest and do not overlap: at the first
+// position where some pattern matches, the longest one wins and the
+// search goes on after it.
+type Matcher struct {
+	patterns []string
+	fold     bool
+
+	edges map[uint64]int32 // node<<32 | symbol -> node
+	fail  []int32
+	dict  []int32 // the next node on the fail chain with out >= 0
+	out   []int32 // pattern index, or -1
+	depth []int32 // length in symbols
+
+	maxDepth int
+}
+
+// NewMatcher builds a Matcher for patterns, which must not be empty.
+// With fold, the patterns match under simple case folding like
+// EqualFold.
+func NewMatcher(patterns []string, fold bool) (*Matcher, error) {
+	m := &Matcher{
+		patterns: append([]string(nil), patterns...),
+		fold:     fold,
+		edges:    make(map[uint64]int32),
+	}
+	m.addNode(0)
+
+	children := [][]int32{nil}
+	for i, p := range patterns {
+		if p == "" {
+			return nil, errors.New("empty pattern")
+		}
+		node := int32(0)
+		m.symbols(p, func(_, _ int, c int32) bool {
+			next, ok := m.edges[edgeKey(node, c)]
+			if !ok {
+				next = m.addNode(m.depth[node] + 1)
+				m.edges[edgeKey(node, c)] = next
+				children[node] = append(children[node], c)
+				children = append(children, nil)
+			}
+			node = next
+			return true
+		})
+		if m.out[node] < 0 {
+			m.out[node] = int32(i)
+		}
+		if d := int(m.depth[node]); d > m.maxDepth {
+			m.maxDepth = d
+		}
+	}
+
+	// breadth-first, the fail links point to shallower nodes
+	queue := []int32{0}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, c := range children[node] {
+			child := m.edges[edgeKey(node, c)]
+			if node != 0 {
+				m.fail[child] = m.step(m.fail[node], c)
+			}
+			if f := m.fail[child]; m.out[f] >= 0 {
+				m.dict[child] = f
+			} else {
+				m.dict[child] = m.dict[f]
+			}
+			queue = append(queue, child)
+		}
+	}
+	return m, nil
+}
+
+func (m *Matcher) addNode(depth int32) int32 {
+	m.fail = append(m.fail, 0)
+	m.dict = append(m.dict, -1)
+	m.out = append(m.out, -1)
+	m.depth = append(m.depth, depth)
+	return int32(len(m.out) - 1)
+}
+
+func edgeKey(node, c int32) uint64 {
+	return uint64(node)<<32 | uint64(uint32(c))
+}
+
+// step follows the edge c from node, or the fail links.
+func (m *Matcher) step(node, c int32) int32 {
+	for {
+		if next, ok := m.edges[edgeKey(node, c)]; ok {
+			return next
+		}
+		if node == 0 {
+			return 0
+		}
+		node = m.fail[node]
+	}
+}
+
+// symbols calls f with the byte range and the symbol of every byte of
+// s, or with fold, of every rune folded to the least rune of its orbit,
+// until f returns false. Invalid UTF-8 bytes are symbols of their own.
+func (m *Matcher) symbols(s string, f func(start, end int, c int32) bool) {
+	if !m.fold {
+		for i := 0; i < len(s); i++ {
+			if !f(i, i+1, int32(s[i])) {
+				return
+			}
+		}
+		return
+	}
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		c := foldRune(r)
+		if r == utf8.RuneError && size == 1 {
+			c = -1 - int32(s[i])
+		}
+		if !f(i, i+size, c) {
+			return
+		}
+		i += size
+	}
+}
+
+// foldRune returns the least rune equal to r under simple folding.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}
+	least := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < least {
+			least = f
+		}
+	}
+	return least
+}
+
+// Contains reports whether any pattern occurs in s.
+func (m *Matcher) Contains(s string) bool {
+	found := false
+	node := int32(0)
+	m.symbols(s, func(_, _ int, c int32) bool {
+		node = m.step(node, c)
+		found = m.out[node] >= 0 || m.dict[node] >= 0
+		return !found
+	})
+	return found
+}
+
+// MatcherMatch is a match of pattern Pattern at s[Start:End].
+type MatcherMatch struct {
+	Pattern    int
+	Start, End int
+}
+
+// FindAll returns the matches in s.
+func (m *Matcher) FindAll(s string) []MatcherMatch {
+	var matches []MatcherMatch
+	m.scan(s, func(match MatcherMatch) {
+		matches = append(matches, match)
+	})
+	return matches
+}
+
+// Pattern returns pattern i.
+func (m *Matcher) Pattern(i int) string {
+	return m.patterns[i]
+}
+
+// scan calls yield with the matches in s, in order.
+//
+// A match found is pending until no match found later can start before
+// it, or at the same byte and be longer: matches found later start at
+// or after the start of the partial match held by the current node.
+func (m *Matcher) scan(s string, yield func(MatcherMatch)) {
+	if m.maxDepth == 0 {
+		return
+	}
+
+	var pending []MatcherMatch
+	last := 0 // end of the last match
+
+	flush := func(frontier int) {
+		for {
+			best := -1
+			for i, p := range pending {
+				if best < 0 || p.Start < pending[best].Start ||
+					p.Start == pending[best].Start && p.End > pending[best].End {
+					best = i
+				}
+			}
+			if best < 0 || pending[best].Start >= frontier {
+				return
+			}
+			match := pending[best]
+			yield(match)
+			last = match.End
+
+			kept := pending[:0]
+			for _, p := range pending {
+				if p.Start >= last {
+					kept = append(kept, p)
+				}
+			}
+			pending = kept
+		}
+	}
+
+	// starts of the last maxDepth symbols
+	starts := make([]int, m.maxDepth)
+	n := 0
+	node := int32(0)
+	m.symbols(s, func(start, end int, c int32) bool {
+		starts[n%len(starts)] = start
+		n++
+		node = m.step(node, c)
+
+		o := node
+		if m.out[o] < 0 {
+			o = m.dict[o]
+		}
+		for ; o >= 0; o = m.dict[o] {
+			if start := starts[(n-int(m.depth[o]))%len(starts)]; start >= last {
+				pending = append(pending, MatcherMatch{int(m.out[o]), start, end})
+			}
+		}
+
+		frontier := end
+		if d := int(m.depth[node]); d > 0 {
+			frontier = starts[(n-d)%len(starts)]
+		}
+		flush(frontier)
+		return true
+	})
+	flush(len(s) + 1)
+}
+
+// newMatcher returns strings.NewMatcher, with luaIndex the matchers it
+// builds return 1-based starts.
+func newMatcher(luaIndex bool) lua.LGFunction {
+	base := 0
+	if luaIndex {
+		base = 1
+	}
+
+	return func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		opts := L.OptTable(2, L.NewTable())
+
+		patterns := make([]string, 0, tbl.Len())
+		for i := 1; i <= tbl.Len(); i++ {
+			v := tbl.RawGetInt(i)
+			if !lua.LVCanConvToString(v) {
+				L.ArgError(1, "string expected in pattern list, got "+v.Type().String())
+			}
+			patterns = append(patterns, lua.LVAsString(v))
+		}
+
+		m, err := NewMatcher(patterns, lua.LVAsBool(opts.RawGetString("fold")))
+		if err != nil {
+			L.ArgError(1, err.Error())
+		}
+		pushMatcher(L, m, base)
+		return 1
+	}
+}
+
+func registerMatcherType(L *lua.LState, mod *lua.LTable, luaIndex bool) {
+	matcherMetatable(L)
+	L.SetField(mod, "NewMatcher", L.NewFunction(newMatcher(luaIndex)))
+}
+
+func matcherMetatable(L *lua.LState) lua.LValue {
+	if mt := L.GetTypeMetatable(luaMatcherTypeName); mt != lua.LNil {
+		return mt
+	}
+	mt := L.NewTypeMetatable(luaMatcherTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), matcherMethods))
+	return mt
+}
+
+// luaMatcher is held by a strings.Matcher userdata: the methods are
+// shared by every strings module of a state, so the userdata carries the
+// base of the starts it returns, 1 if built with Options.LuaIndex.
+type luaMatcher struct {
+	m    *Matcher
+	base int
+}
+
+// PushMatcher pushes m as a strings.Matcher userdata, m may be pushed
+// to other LStates too. The starts it returns are 0-based.
+func PushMatcher(L *lua.LState, m *Matcher) {
+	pushMatcher(L, m, 0)
+}
+
+func pushMatcher(L *lua.LState, m *Matcher, base int) {
+	ud := L.NewUserData()
+	ud.Value = &luaMatcher{m: m, base: base}
+	L.SetMetatable(ud, matcherMetatable(L))
+	L.Push(ud)
+}
+
+// CheckMatcher returns the *Matcher held by the userdata at position n
+// of the stack, raising an argument error otherwise.
+func CheckMatcher(L *lua.LState, n int) *Matcher {
+	return checkLuaMatcher(L, n).m
+}
+
+func checkLuaMatcher(L *lua.LState, n int) *luaMatcher {
+	ud := L.CheckUserData(n)
+	if lm, ok := ud.Value.(*luaMatcher); ok {
+		return lm
+	}
+	L.ArgError(n, "strings.Matcher expected")
+	return nil
+}
+
+// ToMatcher returns the *Matcher held by lv, if any.
+func ToMatcher(lv lua.LValue) (*Matcher, bool) {
+	if ud, ok := lv.(*lua.LUserData); ok {
+		if lm, ok := ud.Value.(*luaMatcher); ok {
+			return lm.m, true
+		}
+	}
+	return nil, false
+}
+
+var matcherMethods = map[string]lua.LGFunction{
+	"Contains": func(L *lua.LState) int {
+		m := CheckMatcher(L, 1)
+		s := L.CheckString(2)
+
+		ret := m.Contains(s)
+		return helper.RetBool(L, ret)
+	},
+	"Count": func(L *lua.LState) int {
+		m := CheckMatcher(L, 1)
+		s := L.CheckString(2)
+
+		ret := 0
+		m.scan(s, func(MatcherMatch) { ret++ })
+		return helper.RetInt(L, ret)
+	},
+	// FindAll returns the list of the matches as {pattern, start, end},
+	// where start is 0-based and s:sub(start + 1, end) is the match.
+	// With Options.LuaIndex the start is 1-based, s:sub(start, end).
+	"FindAll": func(L *lua.LState) int {
+		lm := checkLuaMatcher(L, 1)
+		m := lm.m
+		s := L.CheckString(2)
+
+		matches := m.FindAll(s)
+		entry := func(i int) lua.LValue {
+			tbl := L.CreateTable(3, 0)
+			tbl.Append(lua.LString(m.Pattern(matches[i].Pattern)))
+			tbl.Append(lua.LNumber(matches[i].Start + lm.base))
+			tbl.Append(lua.LNumber(matches[i].End))
+			return tbl
+		}
+
+		if L.Get(3) != lua.LNil {
+			tbl := L.CheckTable(3)
+			fillList(tbl, len(matches), entry)
+			L.Push(tbl)
+			L.Push(lua.LNumber(len(matches)))
+			return 2
+		}
+		tbl := L.CreateTable(len(matches), 0)
+		for i := range matches {
+			tbl.Append(entry(i))
+		}
+		L.Push(tbl)
+		return 1
+	},
+	// ReplaceAll replaces the matches by repl, or by repl(match,
+	// pattern). Like string.gsub, a nil or false result keeps the match.
+	"ReplaceAll": func(L *lua.LState) int {
+		m := CheckMatcher(L, 1)
+		s := L.CheckString(2)
+		L.CheckTypes(3, lua.LTString, lua.LTNumber, lua.LTFunction)
+		repl := L.Get(3)
+
+		var b strings.Builder
+		last := 0
+		m.scan(s, func(match MatcherMatch) {
+			b.WriteString(s[last:match.Start])
+			if fn, ok := repl.(*lua.LFunction); ok {
+				text := s[match.Start:match.End]
+				b.WriteString(callFunc_Match_ret_String(L, "strings.Matcher.ReplaceAll", fn, text,
+					lua.LString(text), lua.LString(m.Pattern(match.Pattern))))
+			} else {
+				b.WriteString(lua.LVAsString(repl))
+			}
+			last = match.End
+		})
+		b.WriteString(s[last:])
+		return helper.RetString(L, b.String())
+	},
+}
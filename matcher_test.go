@@ -0,0 +1,220 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+// findAllNaive is the leftmost-longest search FindAll must agree with.
+func findAllNaive(patterns []string, s string, fold bool) []lua_strings.MatcherMatch {
+	var matches []lua_strings.MatcherMatch
+	for i := 0; i < len(s); {
+		best := lua_strings.MatcherMatch{Pattern: -1}
+		for k, p := range patterns {
+			end, ok := i+len(p), false
+			if fold {
+				// the test patterns fold to patterns of the same length
+				ok = end <= len(s) && strings.EqualFold(s[i:end], p)
+			} else {
+				ok = strings.HasPrefix(s[i:], p)
+			}
+			if ok && (best.Pattern < 0 || end > best.End) {
+				best = lua_strings.MatcherMatch{Pattern: k, Start: i, End: end}
+			}
+		}
+		if best.Pattern < 0 {
+			i++
+			continue
+		}
+		matches = append(matches, best)
+		i = best.End
+	}
+	return matches
+}
+
+func TestMatcherFindAll(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomString := func(alphabet string, n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+
+	for _, fold := range []bool{false, true} {
+		for round := 0; round < 500; round++ {
+			var patterns []string
+			for i := 0; i < 1+rnd.Intn(6); i++ {
+				p := randomString("abcAB", 1+rnd.Intn(4))
+				if !containsString(patterns, p) {
+					patterns = append(patterns, p)
+				}
+			}
+			s := randomString("abcAB", rnd.Intn(30))
+
+			m, err := lua_strings.NewMatcher(patterns, fold)
+			require.NoError(t, err)
+
+			expected := findAllNaive(patterns, s, fold)
+			got := m.FindAll(s)
+			// patterns equal under folding are the same, keep the first
+			for i := range expected {
+				for k, p := range patterns[:expected[i].Pattern] {
+					if fold && strings.EqualFold(p, patterns[expected[i].Pattern]) {
+						expected[i].Pattern = k
+						break
+					}
+				}
+			}
+			require.Equal(t, expected, got, "patterns: %q, s: %q, fold: %v", patterns, s, fold)
+			require.Equal(t, len(expected) > 0, m.Contains(s), "patterns: %q, s: %q, fold: %v", patterns, s, fold)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMatcher(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+	err := L.DoString(`
+		local strings = require("strings")
+
+		local m = strings.NewMatcher({"he", "she", "his", "hers"})
+		assert(m:Contains("ushers"))
+		assert(not m:Contains("xyz"))
+		assert(m:Count("ushers his") == 2)
+
+		local all = m:FindAll("ushers his")
+		assert(#all == 2)
+		assert(all[1][1] == "she" and all[1][2] == 1 and all[1][3] == 4)
+		assert(all[2][1] == "his" and ("ushers his"):sub(all[2][2] + 1, all[2][3]) == "his")
+
+		local dst = {1, 2, 3}
+		local r, n = m:FindAll("he", dst)
+		assert(r == dst and n == 1 and #dst == 1 and dst[1][1] == "he")
+
+		assert(m:ReplaceAll("ushers his", "*") == "u*rs *")
+		assert(m:ReplaceAll("she", function(match, pattern)
+			assert(match == pattern)
+			return "<" .. match .. ">"
+		end) == "<she>")
+		assert(m:ReplaceAll("she his", function(match)
+			if match == "his" then return nil end
+			return 1
+		end) == "1 his")
+
+		-- leftmost, then longest
+		m = strings.NewMatcher({"ab", "abcd", "bc", "d"})
+		local t = {}
+		for _, e in ipairs(m:FindAll("abcxabcd")) do
+			t[#t + 1] = e[1]
+		end
+		assert(table.concat(t, ",") == "ab,abcd")
+
+		-- folding, positions are bytes of s
+		m = strings.NewMatcher({"straße", "kelvin", "ΣΑΣ"}, {fold = true})
+		all = m:FindAll("STRAßE \226\132\170elvin σας")
+		assert(#all == 3)
+		assert(all[1][1] == "straße" and all[1][3] == 7)
+		assert(all[2][1] == "kelvin" and all[2][2] == 8 and all[2][3] == 16)
+		assert(all[3][1] == "ΣΑΣ")
+		assert(not strings.NewMatcher({"kelvin"}):Contains("KELVIN"))
+
+		assert(strings.NewMatcher({}):Count("abc") == 0)
+		assert(strings.NewMatcher({1, 2}):ReplaceAll("123", "x") == "xx3")
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`strings.NewMatcher({"a", ""})`, "bad argument #1 to NewMatcher (empty pattern)"},
+		{`strings.NewMatcher({"a", {}})`, "bad argument #1 to NewMatcher (string expected in pattern list, got table)"},
+		{`strings.NewMatcher("a")`, "bad argument #1 to NewMatcher (table expected, got string)"},
+		{`strings.NewMatcher({"a"}).Count(strings.NewBuilder(), "a")`, "strings.Matcher expected"},
+		{`strings.NewMatcher({"a"}):ReplaceAll("a", {})`, "bad argument #3 to ReplaceAll (string or number or function expected, got table)"},
+		{`strings.NewMatcher({"a"}):ReplaceAll("a", function() return {} end)`,
+			`strings.Matcher.ReplaceAll: callback failed on match "a": string expected as result, got table`},
+	}
+	for i, tt := range tests {
+		err := L.DoString(`local strings = require("strings"); ` + tt.code)
+		require.ErrorContains(t, err, tt.err, "case %d: %s", i, tt.code)
+	}
+}
+
+func TestMatcherLuaIndex(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		LuaIndex: true,
+	})
+	L.PreloadModule("strings0", lua_strings.Loader)
+
+	err := L.DoString(`
+		local s = "ushers his"
+		local all = require("strings").NewMatcher({"she", "his"}):FindAll(s)
+		assert(all[1][2] == 2 and all[1][3] == 4 and s:sub(all[1][2], all[1][3]) == "she")
+		assert(s:sub(all[2][2], all[2][3]) == "his")
+
+		-- matchers of a 0-based module keep 0-based starts
+		all = require("strings0").NewMatcher({"she"}):FindAll(s)
+		assert(all[1][2] == 1)
+	`)
+	require.NoError(t, err)
+}
+
+func TestMatcherShared(t *testing.T) {
+	m, err := lua_strings.NewMatcher([]string{"foo", "bar"}, true)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			L := lua.NewState()
+			defer L.Close()
+
+			L.PreloadModule("strings", lua_strings.Loader)
+			require.NoError(t, L.DoString(`require("strings")`))
+
+			lua_strings.PushMatcher(L, m)
+			L.SetGlobal("m", L.Get(-1))
+			L.Pop(1)
+
+			for k := 0; k < 100; k++ {
+				require.NoError(t, L.DoString(`assert(m:Count("FOO bar Baz") == 2)`))
+			}
+
+			got, ok := lua_strings.ToMatcher(L.GetGlobal("m"))
+			require.True(t, ok)
+			require.Same(t, m, got)
+		}()
+	}
+	wg.Wait()
+}
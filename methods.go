@@ -0,0 +1,121 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"sort"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// MethodConflict tells InstallStringMethods what to do with a name the
+// string metatable already has, usually from the string library.
+type MethodConflict int
+
+const (
+	// KeepExisting leaves the existing method in place.
+	KeepExisting MethodConflict = iota
+	// ReplaceExisting installs the strings function over it.
+	ReplaceExisting
+	// RaiseOnConflict raises a Lua error naming the method.
+	RaiseOnConflict
+)
+
+// stringMethodsSkipped are the functions whose first argument is not
+// the subject string.
+var stringMethodsSkipped = map[string]bool{
+	"FromRunes": true,
+	"Join":      true,
+}
+
+// stringMethodsSubjectSecond are the functions whose subject string is
+// the second argument.
+var stringMethodsSubjectSecond = map[string]bool{
+	"Map":            true,
+	"ToLowerSpecial": true,
+	"ToTitleSpecial": true,
+	"ToUpperSpecial": true,
+}
+
+// InstallStringMethods makes the strings functions callable as methods
+// of every Lua string: s:ToUpper() is strings.ToUpper(s),
+// s:Map(fn) is strings.Map(fn, s) and s:ToUpperSpecial("turkish") is
+// strings.ToUpperSpecial("turkish", s).
+//
+// The methods are set on the __index table of the string metatable,
+// which in gopher-lua is the string library itself.
+func InstallStringMethods(L *lua.LState, conflict MethodConflict) {
+	installStringMethods(L, moduleFuncs(Options{}), conflict)
+}
+
+func installStringMethods(L *lua.LState, funcs map[string]lua.LGFunction, conflict MethodConflict) {
+	index := stringMethodsIndex(L)
+
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		if !stringMethodsSkipped[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// raise before installing anything, the metatable is shared
+	if conflict == RaiseOnConflict {
+		for _, name := range names {
+			if index.RawGetString(name) != lua.LNil {
+				L.RaiseError("strings: string method '%s' already exists", name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if conflict == KeepExisting && index.RawGetString(name) != lua.LNil {
+			continue
+		}
+
+		fn := L.NewFunction(funcs[name])
+		if stringMethodsSubjectSecond[name] {
+			fn = subjectSecond(L, fn)
+		}
+		index.RawSetString(name, fn)
+	}
+}
+
+// stringMethodsIndex returns the __index table of the string metatable,
+// creating it if needed.
+func stringMethodsIndex(L *lua.LState) *lua.LTable {
+	mt, ok := L.GetMetatable(lua.LString("")).(*lua.LTable)
+	if !ok {
+		mt = L.NewTable()
+		L.SetMetatable(lua.LString(""), mt)
+	}
+
+	old := L.GetField(mt, "__index")
+	if index, ok := old.(*lua.LTable); ok {
+		return index
+	}
+
+	// chain to a non-table __index
+	index := L.NewTable()
+	if old != lua.LNil {
+		meta := L.NewTable()
+		L.SetField(meta, "__index", old)
+		L.SetMetatable(index, meta)
+	}
+	L.SetField(mt, "__index", index)
+	return index
+}
+
+// subjectSecond adapts fn(x, s) to be called as s:fn(x).
+func subjectSecond(L *lua.LState, fn *lua.LFunction) *lua.LFunction {
+	return L.NewFunction(func(L *lua.LState) int {
+		top := L.GetTop()
+		L.Push(fn)
+		L.Push(L.Get(2))
+		L.Push(L.Get(1))
+		L.Call(2, lua.MultRet)
+		return L.GetTop() - top
+	})
+}
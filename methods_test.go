@@ -0,0 +1,90 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestStringMethods(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		StringMethods: true,
+	})
+
+	err := L.DoString(`
+		local strings = require("strings")
+
+		local s = "hello, world"
+		assert(s:ToUpper() == "HELLO, WORLD")
+		assert(s:HasPrefix("hello"))
+		assert(s:Index("world") == 7)
+		assert(("a,b"):Split(",")[2] == "b")
+		assert(("  x  "):TrimSpace() == "x")
+
+		local before, after, found = ("k=v"):Cut("=")
+		assert(before == "k" and after == "v" and found)
+
+		assert(("abc"):Map(function(r) return r + 1 end) == "bcd")
+		assert(("abc"):IndexFunc("letter") == 0)
+
+		-- the string library is still there
+		assert(s:upper() == "HELLO, WORLD")
+		assert(string.len(s) == 12)
+		assert(s.Join == nil)
+	`)
+	require.NoError(t, err)
+}
+
+func TestInstallStringMethods(t *testing.T) {
+	tests := []struct {
+		conflict lua_strings.MethodConflict
+		expected string
+		err      string
+	}{
+		{lua_strings.KeepExisting, "builtin", ""},
+		{lua_strings.ReplaceExisting, "HELLO", ""},
+		{lua_strings.RaiseOnConflict, "", "string method 'ToUpper' already exists"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+
+		// a string library function of the same name
+		require.NoError(t, L.DoString(`
+			string.ToUpper = function(s) return "builtin" end
+		`))
+
+		L.Push(L.NewFunction(func(L *lua.LState) int {
+			lua_strings.InstallStringMethods(L, tests[i].conflict)
+			return 0
+		}))
+		err := L.PCall(0, 0, nil)
+		if tests[i].err != "" {
+			require.ErrorContains(t, err, tests[i].err, "case %d", i)
+
+			// no method was installed before the conflict
+			require.NoError(t, L.DoString(`assert(string.Clone == nil and string.Contains == nil)`), "case %d", i)
+			L.Close()
+			continue
+		}
+		require.NoError(t, err, "case %d", i)
+
+		require.NoError(t, L.DoString(`
+			result = ("hello"):ToUpper()
+			assert(("a b"):Fields()[2] == "b")
+		`))
+		require.Equal(t, lua.LString(tests[i].expected), L.GetGlobal("result"), "case %d", i)
+
+		L.Close()
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// patternFuncs are string.find, string.match, string.gmatch and
+// string.gsub of Lua 5.1 running on compiled patterns, see luaPattern.
+// Positions are 1-based like in Lua, whatever Options.LuaIndex says.
+// As in Lua 5.4, a leading '^' anchors GMatch to the start of s.
+var patternFuncs = map[string]lua.LGFunction{
+	"Find": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		pattern := L.CheckString(2)
+		init := luaFindInit(len(s), L.OptInt(3, 1))
+		plain := lua.LVAsBool(L.Get(4))
+
+		if plain || !strings.ContainsAny(pattern, luaPatternSpecials) {
+			i := strings.Index(s[init:], pattern)
+			if i < 0 {
+				L.Push(lua.LNil)
+				return 1
+			}
+			L.Push(lua.LNumber(init + i + 1))
+			L.Push(lua.LNumber(init + i + len(pattern)))
+			return 2
+		}
+
+		lp := checkLuaPattern(L, "Find", pattern)
+		caps := lp.find(s, init, isASCII(s))
+		if caps == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lua.LNumber(caps[0] + 1))
+		L.Push(lua.LNumber(caps[1]))
+		return 2 + pushCaptures(L, lp, s, caps, false)
+	},
+	"GMatch": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		pattern := L.CheckString(2)
+
+		lp := checkLuaPattern(L, "GMatch", pattern)
+		ascii := isASCII(s)
+		src := 0
+		L.Push(L.NewFunction(func(L *lua.LState) int {
+			if src > len(s) {
+				L.Push(lua.LNil)
+				return 1
+			}
+			caps := lp.find(s, src, ascii)
+			if caps == nil {
+				src = len(s) + 1
+				L.Push(lua.LNil)
+				return 1
+			}
+			src = caps[1]
+			if caps[1] == caps[0] {
+				src++
+			}
+			if lp.anchor {
+				src = len(s) + 1
+			}
+			return pushCaptures(L, lp, s, caps, true)
+		}))
+		return 1
+	},
+	"GSub": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		pattern := L.CheckString(2)
+		L.CheckTypes(3, lua.LTString, lua.LTNumber, lua.LTTable, lua.LTFunction)
+		repl := L.Get(3)
+		limit := L.OptInt(4, len(s)+1)
+
+		lp := checkLuaPattern(L, "GSub", pattern)
+		ascii := isASCII(s)
+
+		var b strings.Builder
+		src, n := 0, 0
+		for n < limit && src <= len(s) {
+			caps := lp.find(s, src, ascii)
+			if caps == nil {
+				break
+			}
+			n++
+			b.WriteString(s[src:caps[0]])
+			b.WriteString(gsubValue(L, lp, s, caps, repl))
+
+			src = caps[1]
+			if caps[1] == caps[0] {
+				if src < len(s) {
+					b.WriteByte(s[src])
+				}
+				src++
+			}
+			if lp.anchor {
+				break
+			}
+		}
+		if src < len(s) {
+			b.WriteString(s[src:])
+		}
+
+		L.Push(lua.LString(b.String()))
+		L.Push(lua.LNumber(n))
+		return 2
+	},
+	"Match": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		pattern := L.CheckString(2)
+		init := luaFindInit(len(s), L.OptInt(3, 1))
+
+		lp := checkLuaPattern(L, "Match", pattern)
+		caps := lp.find(s, init, isASCII(s))
+		if caps == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		return pushCaptures(L, lp, s, caps, true)
+	},
+}
+
+func checkLuaPattern(L *lua.LState, name, pattern string) *luaPattern {
+	lp, err := compileLuaPattern(pattern)
+	if err != nil {
+		L.RaiseError("strings.%s: %s", name, err.Error())
+	}
+	return lp
+}
+
+// luaFindInit converts the 1-based init of Find and Match, negative
+// from the end, to a byte offset in a string of length n.
+func luaFindInit(n, init int) int {
+	if init < 0 {
+		init += n + 1
+	}
+	switch {
+	case init < 1:
+		return 0
+	case init > n:
+		return n
+	}
+	return init - 1
+}
+
+// pushCaptures pushes the captures of a match, or the whole match if
+// the pattern has none and whole is set, and returns their count.
+func pushCaptures(L *lua.LState, lp *luaPattern, s string, caps []int, whole bool) int {
+	if len(lp.position) == 0 {
+		if !whole {
+			return 0
+		}
+		L.Push(lua.LString(s[caps[0]:caps[1]]))
+		return 1
+	}
+	for i := range lp.position {
+		L.Push(captureValue(lp, s, caps, i))
+	}
+	return len(lp.position)
+}
+
+// captureValue returns capture i, its 1-based position for a position
+// capture. With no captures, capture 0 is the whole match.
+func captureValue(lp *luaPattern, s string, caps []int, i int) lua.LValue {
+	if len(lp.position) == 0 {
+		return lua.LString(s[caps[0]:caps[1]])
+	}
+	start, end := caps[2+2*i], caps[3+2*i]
+	if lp.position[i] {
+		return lua.LNumber(start + 1)
+	}
+	return lua.LString(s[start:end])
+}
+
+// gsubValue returns the replacement of a match by repl, which is a
+// string with %0-%9, a table indexed by the first capture, or a function
+// of the captures. A nil or false value keeps the match.
+func gsubValue(L *lua.LState, lp *luaPattern, s string, caps []int, repl lua.LValue) string {
+	match := s[caps[0]:caps[1]]
+
+	switch repl := repl.(type) {
+	case *lua.LTable:
+		value := L.GetTable(repl, captureValue(lp, s, caps, 0))
+		ret, ok := matchReplacement(value, match)
+		if !ok {
+			L.RaiseError("strings.GSub: invalid replacement value (a %s)", value.Type().String())
+		}
+		return ret
+	case *lua.LFunction:
+		args := make([]lua.LValue, max(len(lp.position), 1))
+		for i := range args {
+			args[i] = captureValue(lp, s, caps, i)
+		}
+		return callFunc_Match_ret_String(L, "strings.GSub", repl, match, args...)
+	}
+	return gsubString(L, lp, s, caps, lua.LVAsString(repl))
+}
+
+// gsubString expands repl for a match: %0 is the match, %1-%9 the
+// captures, and % followed by any other character is that character.
+func gsubString(L *lua.LState, lp *luaPattern, s string, caps []int, repl string) string {
+	if strings.IndexByte(repl, '%') < 0 {
+		return repl
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(repl) {
+			L.RaiseError("strings.GSub: %s", "invalid use of '%' in replacement string")
+		}
+		switch c = repl[i]; {
+		case c == '0':
+			b.WriteString(s[caps[0]:caps[1]])
+		case isDigit(c):
+			n := int(c - '1')
+			if n >= len(lp.position) && !(n == 0 && len(lp.position) == 0) {
+				L.RaiseError("strings.GSub: invalid capture index %%%c in replacement string", c)
+			}
+			b.WriteString(lua.LVAsString(captureValue(lp, s, caps, n)))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,323 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func setupPatternTest(t *testing.T) *lua.LState {
+	t.Helper()
+
+	L := lua.NewState()
+	L.PreloadModule("strings", lua_strings.Loader)
+	require.NoError(t, L.DoString(`
+		strings = require("strings")
+
+		-- results of a call as one string, for comparisons
+		function results(...)
+			local t = {}
+			for i = 1, select("#", ...) do
+				t[i] = type((select(i, ...))) .. ":" .. tostring((select(i, ...)))
+			end
+			return table.concat(t, ",")
+		end
+
+		-- string.match returns nothing instead of nil
+		function match(...)
+			local r = {string.match(...)}
+			if #r == 0 then
+				return nil
+			end
+			return unpack(r)
+		end
+
+		function gmatch(gmatch, s, p)
+			local t = {}
+			for a, b in gmatch(s, p) do
+				t[#t + 1] = results(a, b)
+			end
+			return table.concat(t, ";")
+		end
+	`))
+	return L
+}
+
+// luaQuote quotes s for Lua 5.1, which has no \x escapes.
+func luaQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func doPatternString(t *testing.T, L *lua.LState, code string) string {
+	t.Helper()
+
+	require.NoError(t, L.DoString("result = "+code), code)
+	return L.GetGlobal("result").String()
+}
+
+// The functions agree with the string library for the patterns it
+// handles like Lua 5.1.
+func TestPatternLikeStringLib(t *testing.T) {
+	tests := []struct {
+		s       string
+		pattern string
+	}{
+		{"hello world", "o"},
+		{"hello world", "o w"},
+		{"hello world", "l+"},
+		{"hello world", "l*"},
+		{"hello world", "l-o"},
+		{"hello world", "x?h"},
+		{"hello world", "^h"},
+		{"hello world", "^w"},
+		{"hello world", "d$"},
+		{"hello world", "o$"},
+		{"hello world", "(h)(e)"},
+		{"hello world", "()ll()"},
+		{"hello world", "(l)(l)"},
+		{"hello world", "%a+"},
+		{"hello world", "%A+"},
+		{"hello world", "[%a ]+"},
+		{"hello world", "[^o]+"},
+		{"hello world", "[a-f]"},
+		{"hello world", "[]]"},
+		{"a]b", "[]]"},
+		{"a-b", "[a-]+"},
+		{"a-b", "[%-]"},
+		{"key = value", "(%w+)%s*=%s*(%w+)"},
+		{"  trim me  ", "^%s*(.-)%s*$"},
+		{"THE (quick) fox", "%((%a+)%)"},
+		{"f(a(b)c) d", "%b()"},
+		{"if [[x]] then", "%b[]"},
+		{"no close (here", "%b()"},
+		{"abcabc", "(abc)%1"},
+		{"abab", "(a)(b)%1%2"},
+		{"x = 0x1F;", "0x(%x+)"},
+		{"Tab\there", "%c"},
+		{"a.b,c!", "%p"},
+		{"UPPER lower", "%u+"},
+		{"UPPER lower", "%l+"},
+		{"a1b2c3", "%d"},
+		{"a1b2c3", "%D"},
+		{"a_b c", "[%w_]+"},
+		{"x^y", "x^"},
+		{"x$y", "$y"},
+		{"a.b", "%."},
+		{"abc", ".-"},
+		{"abc", "a*"},
+		{"ñandú", "[\x80-\xff]+"},
+		{"ñandú", "."},
+		{"ñandú", "a.d"},
+		{"\x00a\x00", "%z"},
+		{"aaa", "a-b"},
+		{"aaab", "a-b"},
+	}
+
+	L := setupPatternTest(t)
+	defer L.Close()
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/%s", i, tt.pattern), func(t *testing.T) {
+			for _, f := range []struct{ ours, lib string }{
+				{"strings.Find(%s, %s)", "string.find(%s, %s)"},
+				{"strings.Find(%s, %s, 3)", "string.find(%s, %s, 3)"},
+				{"strings.Find(%s, %s, -2)", "string.find(%s, %s, -2)"},
+				{"strings.Match(%s, %s)", "match(%s, %s)"},
+				{"strings.Match(%s, %s, 2)", "match(%s, %s, 2)"},
+				{"strings.GSub(%s, %s, '<%%0>')", "string.gsub(%s, %s, '<%%0>')"},
+				{"strings.GSub(%s, %s, '<%%1>')", "string.gsub(%s, %s, '<%%1>')"},
+				{"strings.GSub(%s, %s, '', 1)", "string.gsub(%s, %s, '', 1)"},
+				{"strings.GSub(%s, %s, function(a) return tostring(a):upper() end)", "string.gsub(%s, %s, function(a) return tostring(a):upper() end)"},
+				{"gmatch(strings.GMatch, %s, %s)", "gmatch(string.gmatch, %s, %s)"},
+			} {
+				ours := fmt.Sprintf(f.ours, luaQuote(tt.s), luaQuote(tt.pattern))
+				lib := fmt.Sprintf(f.lib, luaQuote(tt.s), luaQuote(tt.pattern))
+				if f.ours[0] != 'g' {
+					ours, lib = "results("+ours+")", "results("+lib+")"
+				}
+				require.Equal(t, doPatternString(t, L, lib), doPatternString(t, L, ours), ours)
+			}
+		})
+	}
+}
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		// frontier
+		{`results(strings.Find("THE (quick) fox", "%f[%a]%a+"))`, "number:1,number:3"},
+		{`gmatch(strings.GMatch, "THE (quick) fox", "%f[%a]%a+")`, "string:THE,nil:nil;string:quick,nil:nil;string:fox,nil:nil"},
+		{`results(strings.GSub("hello world from Lua", "%f[%w]%w+", "<%0>"))`, "string:<hello> <world> <from> <Lua>,number:4"},
+		{`results(strings.Find("abc", "%f[%z]"))`, "number:4,number:3"},
+		{`results(strings.GSub("THE (quick) fox", "%f[%a]", "|"))`, "string:|THE (|quick) |fox,number:3"},
+
+		// Lua 5.1 find init clamps, Lua 5.4 anchors in GMatch
+		{`gmatch(strings.GMatch, "aaa", "^a")`, "string:a,nil:nil"},
+		{`gmatch(strings.GMatch, "aaa", "^")`, "string:,nil:nil"},
+		{`gmatch(strings.GMatch, "^a^a", "%^a")`, "string:^a,nil:nil;string:^a,nil:nil"},
+		{`results(strings.Find("abc", "", 10))`, "number:4,number:3"},
+		{`results(strings.Find("abc", "", 3))`, "number:3,number:2"},
+		{`results(strings.Find("", ""))`, "number:1,number:0"},
+		{`results(strings.Find("abc", "b)"))`, "nil:nil"},
+		{`results(strings.Find("a.b", ".", 1, true))`, "number:2,number:2"},
+		{`results(strings.Find("a+b", "+", 1, 1))`, "number:2,number:2"},
+
+		// gsub counts every match, replacement values
+		{`results(strings.GSub("abc", "b", {b = false}))`, "string:abc,number:1"},
+		{`results(strings.GSub("abc", "%w", {a = 1, b = "B"}))`, "string:1Bc,number:3"},
+		{`results(strings.GSub("abc", "(%w)()", {[2] = "x"}))`, "string:abc,number:3"},
+		{`results(strings.GSub("abc", "%w()", {[2] = "x"}))`, "string:xbc,number:3"},
+		{`results(strings.GSub("hello", "l", "%%%x"))`, "string:he%x%xo,number:2"},
+		{`results(strings.GSub("abc", "()", "%1"))`, "string:1a2b3c4,number:4"},
+		{`results(strings.GSub("abc", "%w", 7))`, "string:777,number:3"},
+		{`results(strings.GSub("abc", "^%w", "-"))`, "string:-bc,number:1"},
+		{`results(strings.GSub("abc", "^", "-"))`, "string:-abc,number:1"},
+		{`results(strings.GSub("abc", "x*", "-"))`, "string:-a-b-c-,number:4"},
+		{`results(strings.GSub("abc", "%w", "-", 0))`, "string:abc,number:0"},
+		{`results(strings.GSub("abc", "%w", "-", -1))`, "string:abc,number:0"},
+
+		// the regexp and the matcher see bytes
+		{`results(strings.Find("é", "."))`, "number:1,number:1"},
+		{`results(strings.Find("xé", "é"))`, "number:2,number:3"},
+		{`results(strings.Match("aé\255", "[\128-\255]+"))`, "string:é\xff"},
+		{`results(strings.Match("é(é)", "%b()"))`, "string:(é)"},
+		{`results(strings.Match("abc", "[^%z\1-\255]"))`, "nil:nil"},
+
+		// methods
+		{`results(("k=v"):Match("(%w+)=(%w+)"))`, "string:k,string:v"},
+	}
+
+	L := setupPatternTest(t)
+	defer L.Close()
+	lua_strings.InstallStringMethods(L, lua_strings.KeepExisting)
+
+	for i, tt := range tests {
+		require.Equal(t, tt.expected, doPatternString(t, L, tt.code), "case %d: %s", i, tt.code)
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`strings.Find("abc", "(b")`, "strings.Find: unfinished capture"},
+		{`strings.Match("abc", "b)")`, "strings.Match: invalid pattern capture"},
+		{`strings.Match("abc", "%")`, "strings.Match: malformed pattern (ends with '%')"},
+		{`strings.GSub("abc", "[a", "")`, "strings.GSub: malformed pattern (missing ']')"},
+		{`strings.GSub("abc", "[%", "")`, "strings.GSub: malformed pattern (missing ']')"},
+		{`strings.Find("abc", "%b(")`, "strings.Find: malformed pattern (missing arguments to '%b')"},
+		{`strings.Find("abc", "%fa")`, "strings.Find: missing '[' after '%f' in pattern"},
+		{`strings.Find("abc", "%1")`, "strings.Find: invalid capture index %1"},
+		{`strings.Find("abc", "(a%1)")`, "strings.Find: invalid capture index %1"},
+		{`strings.Find("abc", "(a)%0")`, "strings.Find: invalid capture index %0"},
+		{`strings.Find("abc", string.rep("()", 33))`, "strings.Find: too many captures"},
+		{`strings.GMatch("abc", "(")`, "strings.GMatch: unfinished capture"},
+		{`strings.GSub("abc", "(b)", "%2")`, "strings.GSub: invalid capture index %2 in replacement string"},
+		{`strings.GSub("abc", "b", "%")`, "strings.GSub: invalid use of '%' in replacement string"},
+		{`strings.GSub("abc", "b", {b = {}})`, "strings.GSub: invalid replacement value (a table)"},
+		{`strings.GSub("abc", "b", {b = true})`, "strings.GSub: invalid replacement value (a boolean)"},
+		{`strings.GSub("abc", "b", function() return {} end)`, `strings.GSub: callback failed on match "b": string expected as result, got table`},
+		{`strings.GSub("abc", "b", function() error("boom") end)`, `strings.GSub: callback failed on match "b"`},
+		{`strings.GSub("abc", "b", true)`, "bad argument #3 to GSub (string or number or table or function expected, got boolean)"},
+	}
+
+	L := setupPatternTest(t)
+	defer L.Close()
+
+	for i, tt := range tests {
+		err := L.DoString(tt.code)
+		require.ErrorContains(t, err, tt.err, "case %d: %s", i, tt.code)
+	}
+}
+
+// TestPatternLargeInput takes minutes if a match costs time in the
+// length of the subject, as when every match rescans it for non-ASCII.
+func TestPatternLargeInput(t *testing.T) {
+	L := setupPatternTest(t)
+	defer L.Close()
+
+	require.NoError(t, L.DoString(`
+		local s = string.rep("ab ", 400000)
+		local r, n = strings.GSub(s, "a", "x")
+		assert(n == 400000 and r == string.rep("xb ", 400000))
+		n = 0
+		for w in strings.GMatch(s, "%a+") do
+			n = n + 1
+		end
+		assert(n == 400000)
+	`))
+}
+
+func BenchmarkGSub(b *testing.B) {
+	L := lua.NewState()
+	defer L.Close()
+	L.PreloadModule("strings", lua_strings.Loader)
+	require.NoError(b, L.DoString(`
+		strings = require("strings")
+		s = string.rep("ab ", 40000)
+	`))
+	fn := L.GetGlobal("strings").(*lua.LTable).RawGetString("GSub")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		L.Push(fn)
+		L.Push(L.GetGlobal("s"))
+		L.Push(lua.LString("a"))
+		L.Push(lua.LString("x"))
+		if err := L.PCall(3, 0, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGSubParallel runs GSub in one LState per goroutine, which
+// should scale with the goroutines as the compiled patterns are shared.
+func BenchmarkGSubParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		L := lua.NewState()
+		defer L.Close()
+		L.PreloadModule("strings", lua_strings.Loader)
+		if err := L.DoString(`strings = require("strings")`); err != nil {
+			b.Error(err)
+			return
+		}
+		fn := L.GetGlobal("strings").(*lua.LTable).RawGetString("GSub")
+
+		for pb.Next() {
+			L.Push(fn)
+			L.Push(lua.LString("hello world"))
+			L.Push(lua.LString("%w+"))
+			L.Push(lua.LString("<%0>"))
+			if err := L.PCall(3, 0, nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"io"
+	"strings"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaReaderTypeName = "strings.Reader"
+
+func registerReaderType(L *lua.LState, mod *lua.LTable) {
+	mt := L.NewTypeMetatable(luaReaderTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), readerMethods))
+	L.SetField(mt, "__len", L.NewFunction(readerMethods["Len"]))
+
+	L.SetField(mod, "NewReader", L.NewFunction(newReader))
+}
+
+func newReader(L *lua.LState) int {
+	s := L.CheckString(1)
+
+	ud := L.NewUserData()
+	ud.Value = strings.NewReader(s)
+	L.SetMetatable(ud, L.GetTypeMetatable(luaReaderTypeName))
+	L.Push(ud)
+	return 1
+}
+
+// CheckReader returns the *strings.Reader held by the userdata at
+// position n of the stack, raising an argument error otherwise.
+func CheckReader(L *lua.LState, n int) *strings.Reader {
+	ud := L.CheckUserData(n)
+	if r, ok := ud.Value.(*strings.Reader); ok {
+		return r
+	}
+	L.ArgError(n, "strings.Reader expected")
+	return nil
+}
+
+// ToReader returns the *strings.Reader held by lv, if any.
+func ToReader(lv lua.LValue) (*strings.Reader, bool) {
+	if ud, ok := lv.(*lua.LUserData); ok {
+		r, ok := ud.Value.(*strings.Reader)
+		return r, ok
+	}
+	return nil, false
+}
+
+// Read errors are returned the Lua way: nil followed by the message.
+func retNilError(L *lua.LState, err error) int {
+	L.Push(lua.LNil)
+	L.Push(lua.LString(err.Error()))
+	return 2
+}
+
+// checkWhence accepts the whence names of Lua's file:seek as well as
+// the io.Seek* constants.
+func checkWhence(L *lua.LState, n int) int {
+	switch v := L.Get(n).(type) {
+	case *lua.LNilType:
+		return io.SeekCurrent
+	case lua.LNumber:
+		return int(v)
+	case lua.LString:
+		switch v {
+		case "set":
+			return io.SeekStart
+		case "cur":
+			return io.SeekCurrent
+		case "end":
+			return io.SeekEnd
+		}
+		L.ArgError(n, "invalid option '"+string(v)+"'")
+	default:
+		L.TypeError(n, lua.LTString)
+	}
+	return 0
+}
+
+var readerMethods = map[string]lua.LGFunction{
+	"Len": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+
+		ret := r.Len()
+		return helper.RetInt(L, ret)
+	},
+	"Read": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+		n := L.CheckInt(2)
+
+		if n < 0 {
+			L.ArgError(2, "negative count")
+		}
+
+		// the count comes from the script, allocate no more than is left
+		buf := make([]byte, min(n, r.Len()))
+		ret, err := r.Read(buf)
+		if err != nil && n > 0 {
+			return retNilError(L, err)
+		}
+		return helper.RetString(L, string(buf[:ret]))
+	},
+	"ReadByte": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+
+		ret, err := r.ReadByte()
+		if err != nil {
+			return retNilError(L, err)
+		}
+		return helper.RetInt(L, int(ret))
+	},
+	"ReadRune": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+
+		ch, size, err := r.ReadRune()
+		if err != nil {
+			return retNilError(L, err)
+		}
+		return helper.Return(L, int(ch), size)
+	},
+	"Reset": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+		s := L.CheckString(2)
+
+		r.Reset(s)
+		return 0
+	},
+	"Seek": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+		offset := L.OptInt64(2, 0)
+		whence := checkWhence(L, 3)
+
+		ret, err := r.Seek(offset, whence)
+		if err != nil {
+			return retNilError(L, err)
+		}
+		return helper.RetInt(L, int(ret))
+	},
+	"Size": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+
+		ret := r.Size()
+		return helper.RetInt(L, int(ret))
+	},
+	"UnreadByte": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+
+		if err := r.UnreadByte(); err != nil {
+			return retNilError(L, err)
+		}
+		return helper.RetBool(L, true)
+	},
+	"UnreadRune": func(L *lua.LState) int {
+		r := CheckReader(L, 1)
+
+		if err := r.UnreadRune(); err != nil {
+			return retNilError(L, err)
+		}
+		return helper.RetBool(L, true)
+	},
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestReader(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+
+	err := L.DoString(`
+		local strings = require("strings")
+
+		local r = strings.NewReader("a世b")
+		assert(r:Size() == 5)
+		assert(r:Len() == 5 and #r == 5)
+
+		assert(r:ReadByte() == string.byte("a"))
+		local ch, size = r:ReadRune()
+		assert(ch == 0x4E16 and size == 3)
+		assert(r:UnreadRune() == true)
+		local ok, err = r:UnreadRune()
+		assert(ok == nil and err ~= nil)
+		assert(r:Read(10) == "世b")
+		r:Seek(1, "set")
+		assert(r:Read(2^40) == "世b")
+
+		local b, err = r:ReadByte()
+		assert(b == nil and err == "EOF")
+		local ch, err = r:ReadRune()
+		assert(ch == nil and err == "EOF")
+		local s, err = r:Read(1)
+		assert(s == nil and err == "EOF")
+		assert(r:Read(0) == "")
+
+		assert(r:Seek(1, "set") == 1)
+		assert(r:Seek(3) == 4)
+		assert(r:Seek() == 4)
+		assert(r:Seek(-1, "end") == 4)
+		assert(r:Seek(0, 0) == 0)
+		local pos, err = r:Seek(-1, "set")
+		assert(pos == nil and err ~= nil)
+
+		local ok, err = r:UnreadByte()
+		assert(ok == nil and err ~= nil)
+		r:ReadByte()
+		assert(r:UnreadByte() == true)
+
+		r:Reset("hello")
+		assert(r:Size() == 5 and r:Read(2) == "he")
+
+		reader = r
+	`)
+	require.NoError(t, err)
+
+	// the host reads the rest through io.Reader
+	r, ok := lua_strings.ToReader(L.GetGlobal("reader"))
+	require.True(t, ok)
+
+	var rd io.Reader = r
+	data, err := io.ReadAll(rd)
+	require.NoError(t, err)
+	require.Equal(t, "llo", string(data))
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		msg  string
+	}{
+		{`strings.NewReader("x"):Read(-1)`, "negative count"},
+		{`strings.NewReader("x"):Seek(0, "bad")`, "invalid option 'bad'"},
+		{`strings.NewReader("x"):Seek(0, {})`, "string expected"},
+		{`strings.NewReader("x").Len(strings.NewBuilder())`, "strings.Reader expected"},
+		{`strings.NewReader()`, "string expected"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(`local strings = require("strings")` + "\n" + tests[i].code)
+		require.Error(t, err, "case %d: %s", i, tests[i].code)
+		require.Contains(t, err.Error(), tests[i].msg, "case %d: %s", i, tests[i].code)
+
+		L.Close()
+	}
+}
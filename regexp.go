@@ -0,0 +1,271 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"regexp"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaRegexpTypeName = "strings.Regexp"
+
+// RegexpLoader loads the strings.regexp module, Go regular expressions
+// in the RE2 syntax.
+func RegexpLoader(L *lua.LState) int {
+	return loadRegexpModule(L, false)
+}
+
+// newRegexpLoader returns the loader of a strings.regexp module whose
+// expressions return 1-based starts with luaIndex, see Options.LuaIndex.
+func newRegexpLoader(luaIndex bool) lua.LGFunction {
+	return func(L *lua.LState) int {
+		return loadRegexpModule(L, luaIndex)
+	}
+}
+
+func loadRegexpModule(L *lua.LState, luaIndex bool) int {
+	mt := L.NewTypeMetatable(luaRegexpTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), regexpMethods))
+	L.SetField(mt, "__tostring", L.NewFunction(regexpMethods["String"]))
+
+	mod := L.NewTable()
+	L.SetFuncs(mod, regexpFuncs(luaIndex))
+	L.Push(mod)
+	return 1
+}
+
+// luaRegexp is held by a strings.Regexp userdata: the methods are shared
+// by every strings.regexp module of a state, so the userdata carries the
+// base of the starts it returns, 1 if its module has Options.LuaIndex.
+type luaRegexp struct {
+	re   *regexp.Regexp
+	base int
+}
+
+type regexpCacheKey struct {
+	expr    string
+	longest bool
+}
+
+var regexpCache = newCompileCache[regexpCacheKey, *regexp.Regexp](256)
+
+// compileRegexp compiles expr, or returns the expression compiled
+// before: a *regexp.Regexp is safe to share between LStates.
+func compileRegexp(expr string, longest bool) (*regexp.Regexp, error) {
+	return regexpCache.get(regexpCacheKey{expr, longest}, func() (*regexp.Regexp, error) {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		if longest {
+			re.Longest()
+		}
+		return re, nil
+	})
+}
+
+func pushRegexp(L *lua.LState, re *regexp.Regexp, base int) {
+	ud := L.NewUserData()
+	ud.Value = &luaRegexp{re: re, base: base}
+	L.SetMetatable(ud, L.GetTypeMetatable(luaRegexpTypeName))
+	L.Push(ud)
+}
+
+// CheckRegexp returns the *regexp.Regexp held by the userdata at
+// position n of the stack, raising an argument error otherwise.
+func CheckRegexp(L *lua.LState, n int) *regexp.Regexp {
+	return checkLuaRegexp(L, n).re
+}
+
+func checkLuaRegexp(L *lua.LState, n int) *luaRegexp {
+	ud := L.CheckUserData(n)
+	if r, ok := ud.Value.(*luaRegexp); ok {
+		return r
+	}
+	L.ArgError(n, "strings.Regexp expected")
+	return nil
+}
+
+// ToRegexp returns the *regexp.Regexp held by lv, if any.
+func ToRegexp(lv lua.LValue) (*regexp.Regexp, bool) {
+	if ud, ok := lv.(*lua.LUserData); ok {
+		if r, ok := ud.Value.(*luaRegexp); ok {
+			return r.re, true
+		}
+	}
+	return nil, false
+}
+
+// regexpFuncs returns the functions of strings.regexp, with luaIndex
+// the expressions they compile return 1-based starts.
+func regexpFuncs(luaIndex bool) map[string]lua.LGFunction {
+	base := 0
+	if luaIndex {
+		base = 1
+	}
+
+	return map[string]lua.LGFunction{
+		"Compile": func(L *lua.LState) int {
+			expr := L.CheckString(1)
+
+			re, err := compileRegexp(expr, false)
+			if err != nil {
+				return retNilError(L, err)
+			}
+			pushRegexp(L, re, base)
+			return 1
+		},
+		"MustCompile": func(L *lua.LState) int {
+			expr := L.CheckString(1)
+
+			re, err := compileRegexp(expr, false)
+			if err != nil {
+				L.RaiseError("strings.regexp: %s", err.Error())
+			}
+			pushRegexp(L, re, base)
+			return 1
+		},
+		"QuoteMeta": func(L *lua.LState) int {
+			s := L.CheckString(1)
+
+			ret := regexp.QuoteMeta(s)
+			return helper.RetString(L, ret)
+		},
+	}
+}
+
+var regexpMethods = map[string]lua.LGFunction{
+	// FindAllString returns the list of the first n matches, all of them
+	// if n is nil or negative.
+	"FindAllString": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+		n := L.OptInt(3, -1)
+
+		ret := re.FindAllString(s, n)
+		return retStringList(L, 4, ret)
+	},
+	// FindString returns the leftmost match, or nil.
+	"FindString": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+
+		loc := re.FindStringIndex(s)
+		if loc == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		return helper.RetString(L, s[loc[0]:loc[1]])
+	},
+	// FindStringIndex returns the 0-based start and the end of the
+	// leftmost match, s:sub(i + 1, j) is the match, or nil. With
+	// Options.LuaIndex the start is 1-based and s:sub(i, j) is the match.
+	"FindStringIndex": func(L *lua.LState) int {
+		r := checkLuaRegexp(L, 1)
+		s := L.CheckString(2)
+
+		loc := r.re.FindStringIndex(s)
+		if loc == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lua.LNumber(loc[0] + r.base))
+		L.Push(lua.LNumber(loc[1]))
+		return 2
+	},
+	// FindStringSubmatch returns the leftmost match at index 1 followed
+	// by the submatches, "" for the groups that did not take part, or
+	// nil. Named groups are also set by name.
+	"FindStringSubmatch": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+
+		m := re.FindStringSubmatch(s)
+		if m == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		tbl := L.CreateTable(len(m), 0)
+		for _, v := range m {
+			tbl.Append(lua.LString(v))
+		}
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				tbl.RawSetString(name, lua.LString(m[i]))
+			}
+		}
+		L.Push(tbl)
+		return 1
+	},
+	// Longest makes future searches leftmost-longest. The compiled
+	// expression may be shared, so the userdata gets its own copy.
+	"Longest": func(L *lua.LState) int {
+		r := checkLuaRegexp(L, 1)
+
+		longest, err := compileRegexp(r.re.String(), true)
+		if err != nil {
+			L.RaiseError("strings.regexp: %s", err.Error())
+		}
+		r.re = longest
+		return 0
+	},
+	"MatchString": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+
+		ret := re.MatchString(s)
+		return helper.RetBool(L, ret)
+	},
+	"NumSubexp": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+
+		ret := re.NumSubexp()
+		return helper.RetInt(L, ret)
+	},
+	"ReplaceAllString": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+		repl := L.CheckString(3)
+
+		ret := re.ReplaceAllString(s, repl)
+		return helper.RetString(L, ret)
+	},
+	// ReplaceAllStringFunc replaces every match by fn(match). Like
+	// string.gsub, a nil or false result keeps the match.
+	"ReplaceAllStringFunc": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+		fn := L.CheckFunction(3)
+
+		ret := re.ReplaceAllStringFunc(s, func(match string) string {
+			return callFunc_Match_ret_String(L, "strings.regexp.ReplaceAllStringFunc", fn, match, lua.LString(match))
+		})
+		return helper.RetString(L, ret)
+	},
+	// Split returns the list of the substrings between the matches, at
+	// most n if n is not nil or negative.
+	"Split": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+		s := L.CheckString(2)
+		n := L.OptInt(3, -1)
+
+		ret := re.Split(s, n)
+		return retStringList(L, 4, ret)
+	},
+	"String": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+
+		ret := re.String()
+		return helper.RetString(L, ret)
+	},
+	"SubexpNames": func(L *lua.LState) int {
+		re := CheckRegexp(L, 1)
+
+		ret := re.SubexpNames()
+		return retStringList(L, 2, ret)
+	},
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func setupRegexpTest(t *testing.T) *lua.LState {
+	t.Helper()
+
+	L := lua.NewState()
+	lua_strings.Preload(L)
+	require.NoError(t, L.DoString(`regexp = require("strings.regexp")`))
+	return L
+}
+
+func TestRegexp(t *testing.T) {
+	L := setupRegexpTest(t)
+	defer L.Close()
+
+	err := L.DoString(`
+		local re = regexp.MustCompile("(?P<key>\\w+)=(?P<value>\\w*)|#")
+		assert(tostring(re) == "(?P<key>\\w+)=(?P<value>\\w*)|#")
+		assert(re:NumSubexp() == 2)
+
+		assert(re:MatchString("a=b"))
+		assert(not re:MatchString("a b"))
+
+		assert(re:FindString("x a=b c=d") == "a=b")
+		assert(re:FindString("none") == nil)
+
+		local s = "x a=b"
+		local i, j = re:FindStringIndex(s)
+		assert(i == 2 and j == 5 and s:sub(i + 1, j) == "a=b")
+		assert(re:FindStringIndex("none") == nil)
+
+		local all = re:FindAllString("a=1 # b=2 c=3")
+		assert(#all == 4 and all[1] == "a=1" and all[2] == "#" and all[4] == "c=3")
+		all = re:FindAllString("a=1 b=2 c=3", 2)
+		assert(#all == 2 and all[2] == "b=2")
+		local dst = {"x", "y", "z"}
+		local r, n = re:FindAllString("a=1", -1, dst)
+		assert(r == dst and n == 1 and #dst == 1)
+
+		local m = re:FindStringSubmatch("-- key=value --")
+		assert(#m == 3 and m[1] == "key=value" and m[2] == "key" and m[3] == "value")
+		assert(m.key == "key" and m.value == "value")
+		m = re:FindStringSubmatch("#")
+		assert(m[1] == "#" and m[2] == "" and m.key == "")
+		assert(re:FindStringSubmatch("none") == nil)
+
+		local names = re:SubexpNames()
+		assert(#names == 3 and names[1] == "" and names[2] == "key" and names[3] == "value")
+
+		assert(re:ReplaceAllString("a=1 b=2", "${value}:${key}") == "1:a 2:b")
+		assert(re:ReplaceAllStringFunc("a=1 b=2 #", function(m)
+			if m == "#" then return nil end
+			return m:upper()
+		end) == "A=1 B=2 #")
+		assert(re:ReplaceAllStringFunc("a=1", function(m) return 7 end) == "7")
+
+		local parts = regexp.MustCompile("\\s*[,;]\\s*"):Split("a , b;c")
+		assert(#parts == 3 and parts[1] == "a" and parts[2] == "b" and parts[3] == "c")
+		parts = regexp.MustCompile(","):Split("a,b,c", 2)
+		assert(#parts == 2 and parts[2] == "b,c")
+
+		assert(regexp.QuoteMeta("1+1=2?") == "1\\+1=2\\?")
+		assert(require("strings").regexp.MustCompile("a|b"):MatchString("b"))
+	`)
+	require.NoError(t, err)
+}
+
+func TestRegexpLongest(t *testing.T) {
+	L := setupRegexpTest(t)
+	defer L.Close()
+
+	err := L.DoString(`
+		local re = regexp.MustCompile("a(|b)")
+		local shared = regexp.MustCompile("a(|b)")
+		assert(re:FindString("ab") == "a")
+		re:Longest()
+		assert(re:FindString("ab") == "ab")
+		-- the compiled expression is cached, other values are not affected
+		assert(shared:FindString("ab") == "a")
+		assert(regexp.MustCompile("a(|b)"):FindString("ab") == "a")
+	`)
+	require.NoError(t, err)
+}
+
+func TestRegexpLuaIndex(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		LuaIndex: true,
+	})
+	L.PreloadModule("strings0", lua_strings.Loader)
+
+	err := L.DoString(`
+		local s = "x a=b"
+		for _, regexp in ipairs({require("strings").regexp, require("strings.regexp")}) do
+			local re = regexp.MustCompile("\\w=\\w")
+			local i, j = re:FindStringIndex(s)
+			assert(i == 3 and j == 5 and s:sub(i, j) == "a=b")
+			re:Longest()
+			assert(re:FindStringIndex(s) == 3)
+		end
+
+		-- expressions of a 0-based module keep 0-based starts
+		local i = require("strings0").regexp.MustCompile("a"):FindStringIndex(s)
+		assert(i == 2)
+	`)
+	require.NoError(t, err)
+}
+
+func TestRegexpErrors(t *testing.T) {
+	L := setupRegexpTest(t)
+	defer L.Close()
+
+	err := L.DoString(`
+		local re, err = regexp.Compile("a(")
+		assert(re == nil and err:find("missing closing %)"))
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`regexp.MustCompile("a(")`, "strings.regexp: error parsing regexp: missing closing )"},
+		{`regexp.MustCompile("a").MatchString(require("strings").NewBuilder(), "a")`, "strings.Regexp expected"},
+		{`regexp.MustCompile("a"):ReplaceAllStringFunc("a", function() return {} end)`,
+			`strings.regexp.ReplaceAllStringFunc: callback failed on match "a": string expected as result, got table`},
+		{`regexp.MustCompile("a"):ReplaceAllStringFunc("a", function() error("boom") end)`,
+			`strings.regexp.ReplaceAllStringFunc: callback failed on match "a"`},
+	}
+	for i, tt := range tests {
+		err := L.DoString(tt.code)
+		require.ErrorContains(t, err, tt.err, "case %d: %s", i, tt.code)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"sort"
+	"strings"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const luaReplacerTypeName = "strings.Replacer"
+
+func registerReplacerType(L *lua.LState, mod *lua.LTable) {
+	mt := L.NewTypeMetatable(luaReplacerTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), replacerMethods))
+
+	L.SetField(mod, "NewReplacer", L.NewFunction(newReplacer))
+}
+
+// newReplacer accepts either a table {[old] = new, ...} or the flat
+// old, new, ... list of strings.NewReplacer.
+//
+// Lua tables have no order, so the pairs of a table are sorted by
+// descending length of old and then bytewise: at a given position the
+// longest old string matches first, the same for every run.
+func newReplacer(L *lua.LState) int {
+	var oldnew []string
+
+	if tbl, ok := L.Get(1).(*lua.LTable); ok && L.GetTop() == 1 {
+		tbl.ForEach(func(key, value lua.LValue) {
+			oldnew = append(oldnew, checkReplacerString(L, key), checkReplacerString(L, value))
+		})
+		sort.Sort(byOldString(oldnew))
+	} else {
+		if L.GetTop()%2 == 1 {
+			L.ArgError(L.GetTop(), "odd argument count")
+		}
+		for i := 1; i <= L.GetTop(); i++ {
+			oldnew = append(oldnew, L.CheckString(i))
+		}
+	}
+
+	ud := L.NewUserData()
+	ud.Value = strings.NewReplacer(oldnew...)
+	L.SetMetatable(ud, L.GetTypeMetatable(luaReplacerTypeName))
+	L.Push(ud)
+	return 1
+}
+
+func checkReplacerString(L *lua.LState, lv lua.LValue) string {
+	if !lua.LVCanConvToString(lv) {
+		L.ArgError(1, "string expected in replacement table, got "+lv.Type().String())
+	}
+	return lua.LVAsString(lv)
+}
+
+// byOldString sorts a flat old, new, ... list by pairs.
+type byOldString []string
+
+func (p byOldString) Len() int { return len(p) / 2 }
+
+func (p byOldString) Less(i, j int) bool {
+	a, b := p[2*i], p[2*j]
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a < b
+}
+
+func (p byOldString) Swap(i, j int) {
+	p[2*i], p[2*j] = p[2*j], p[2*i]
+	p[2*i+1], p[2*j+1] = p[2*j+1], p[2*i+1]
+}
+
+// CheckReplacer returns the *strings.Replacer held by the userdata at
+// position n of the stack, raising an argument error otherwise.
+func CheckReplacer(L *lua.LState, n int) *strings.Replacer {
+	ud := L.CheckUserData(n)
+	if r, ok := ud.Value.(*strings.Replacer); ok {
+		return r
+	}
+	L.ArgError(n, "strings.Replacer expected")
+	return nil
+}
+
+// ToReplacer returns the *strings.Replacer held by lv, if any.
+func ToReplacer(lv lua.LValue) (*strings.Replacer, bool) {
+	if ud, ok := lv.(*lua.LUserData); ok {
+		r, ok := ud.Value.(*strings.Replacer)
+		return r, ok
+	}
+	return nil, false
+}
+
+var replacerMethods = map[string]lua.LGFunction{
+	"Replace": func(L *lua.LState) int {
+		r := CheckReplacer(L, 1)
+		s := L.CheckString(2)
+
+		ret := r.Replace(s)
+		return helper.RetString(L, ret)
+	},
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestReplacer(t *testing.T) {
+	tests := []struct {
+		args   string
+		oldnew []string
+		s      string
+	}{
+		{`{["&"]="&amp;", ["<"]="&lt;", [">"]="&gt;"}`, []string{"&", "&amp;", "<", "&lt;", ">", "&gt;"}, "<a & b>"},
+		{`"&", "&amp;", "<", "&lt;"`, []string{"&", "&amp;", "<", "&lt;"}, "<a & b>"},
+		{`{a="1", ab="2"}`, []string{"ab", "2", "a", "1"}, "abc a ab"},
+		{`{["世界"]="world", ["世"]="x"}`, []string{"世界", "world", "世", "x"}, "世界 世"},
+		{`"a", "1", "ab", "2"`, []string{"a", "1", "ab", "2"}, "abc a ab"},
+		{`{[1]="one"}`, []string{"1", "one"}, "123"},
+		{`{}`, nil, "hello"},
+		{``, nil, "hello"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(`
+			local strings = require("strings")
+			r = strings.NewReplacer(` + tests[i].args + `)
+		`)
+		require.NoError(t, err, "case %d", i)
+
+		expected := strings.NewReplacer(tests[i].oldnew...).Replace(tests[i].s)
+
+		// the compiled replacer is reused across calls
+		for range 2 {
+			L.Push(L.GetField(L.GetGlobal("r"), "Replace"))
+			L.Push(L.GetGlobal("r"))
+			L.Push(lua.LString(tests[i].s))
+			L.Call(2, 1)
+			got := L.ToString(-1)
+			L.Pop(1)
+
+			require.Equal(t, expected, got,
+				"case %d: Lua returned %q but Go returned %q (args: %s, string: %q)",
+				i, got, expected, tests[i].args, tests[i].s)
+		}
+
+		_, ok := lua_strings.ToReplacer(L.GetGlobal("r"))
+		require.True(t, ok)
+
+		L.Close()
+	}
+}
+
+func TestReplacerErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		msg  string
+	}{
+		{`strings.NewReplacer("a")`, "odd argument count"},
+		{`strings.NewReplacer({a=true})`, "string expected in replacement table, got boolean"},
+		{`strings.NewReplacer("a", {})`, "string expected"},
+		{`strings.NewReplacer("a", "b").Replace(strings.NewBuilder(), "x")`, "strings.Replacer expected"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(`local strings = require("strings")` + "\n" + tests[i].code)
+		require.Error(t, err, "case %d: %s", i, tests[i].code)
+		require.Contains(t, err.Error(), tests[i].msg, "case %d: %s", i, tests[i].code)
+
+		L.Close()
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"unicode/utf8"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// runePredicate reports whether the rune r, found at byte offset i and
+// size bytes long, satisfies a condition.
+//
+// The *Func functions of package strings only pass the rune, the
+// functions below mirror them with the position of the rune added.
+type runePredicate func(r rune, i, size int) bool
+
+// luaRunePredicate calls fn with the codepoint, the rune as a string
+// and its byte offset in s.
+func luaRunePredicate(L *lua.LState, name string, fn *lua.LFunction, s string) runePredicate {
+	return func(r rune, i, size int) bool {
+		return callFunc_Rune_ret_Bool(
+			L, name, fn, r, s[i:i+size], i,
+		)
+	}
+}
+
+func not(f runePredicate) runePredicate {
+	return func(r rune, i, size int) bool { return !f(r, i, size) }
+}
+
+// indexFunc is like strings.IndexFunc.
+func indexFunc(s string, f runePredicate) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if f(r, i, size) {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// lastIndexFunc is like strings.LastIndexFunc.
+func lastIndexFunc(s string, f runePredicate) int {
+	for i := len(s); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+		if f(r, i, size) {
+			return i
+		}
+	}
+	return -1
+}
+
+// trimLeftFunc is like strings.TrimLeftFunc.
+func trimLeftFunc(s string, f runePredicate) string {
+	i := indexFunc(s, not(f))
+	if i < 0 {
+		return ""
+	}
+	return s[i:]
+}
+
+// trimRightFunc is like strings.TrimRightFunc.
+func trimRightFunc(s string, f runePredicate) string {
+	return s[:lastEnd(s, lastIndexFunc(s, not(f)))]
+}
+
+// trimFunc is like strings.TrimFunc, offsets are relative to s on both ends.
+func trimFunc(s string, f runePredicate) string {
+	i := indexFunc(s, not(f))
+	if i < 0 {
+		return ""
+	}
+	return s[i:lastEnd(s, lastIndexFunc(s, not(f)))]
+}
+
+// lastEnd returns the end of the rune starting at i, or 0 if i < 0.
+func lastEnd(s string, i int) int {
+	if i < 0 {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return i + size
+}
+
+// fieldsFunc is like strings.FieldsFunc.
+func fieldsFunc(s string, f runePredicate) []string {
+	var fields []string
+	start := -1
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if f(r, i, size) {
+			if start >= 0 {
+				fields = append(fields, s[start:i])
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+		i += size
+	}
+	if start >= 0 {
+		fields = append(fields, s[start:])
+	}
+	return fields
+}
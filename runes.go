@@ -0,0 +1,165 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// runeFuncs returns the functions working with rune offsets.
+//
+// By default offsets are 0-based like the rest of the module, RuneSub
+// slices runes like s[i:j] and a missing index is -1. With luaIndex the
+// positions are 1-based, RuneAt and RuneSub count negative positions
+// from the end like string.sub, and a missing index is nil.
+func runeFuncs(luaIndex bool) map[string]lua.LGFunction {
+	base := 0
+	var notFound lua.LValue = lua.LNumber(-1)
+	if luaIndex {
+		base = 1
+		notFound = lua.LNil
+	}
+
+	return map[string]lua.LGFunction{
+		"ByteToRuneOffset": func(L *lua.LState) int {
+			s := checkUTF8String(L, 1)
+			i := L.CheckInt(2) - base
+
+			if i < 0 || i > len(s) {
+				L.ArgError(2, "byte offset out of range")
+			}
+			if i < len(s) && !utf8.RuneStart(s[i]) {
+				L.ArgError(2, "byte offset not at the start of a rune")
+			}
+
+			ret := utf8.RuneCountInString(s[:i])
+			return helper.RetInt(L, ret+base)
+		},
+		"FromRunes": func(L *lua.LState) int {
+			tbl := L.CheckTable(1)
+
+			b := make([]byte, 0, tbl.Len())
+			for i := 1; i <= tbl.Len(); i++ {
+				r, ok := tbl.RawGetInt(i).(lua.LNumber)
+				if !ok || !utf8.ValidRune(rune(r)) || lua.LNumber(rune(r)) != r {
+					L.ArgError(1, "invalid rune at index "+lua.LNumber(i).String())
+				}
+				b = utf8.AppendRune(b, rune(r))
+			}
+			return helper.RetString(L, string(b))
+		},
+		"RuneAt": func(L *lua.LState) int {
+			s := checkUTF8String(L, 1)
+			i := L.CheckInt(2)
+
+			n := utf8.RuneCountInString(s)
+			if luaIndex && i < 0 {
+				i += n + 1
+			}
+			i -= base
+			if i < 0 || i >= n {
+				L.Push(lua.LNil)
+				return 1
+			}
+
+			ret, _ := utf8.DecodeRuneInString(s[runeByteOffset(s, i):])
+			return helper.RetInt(L, int(ret))
+		},
+		"RuneIndex": func(L *lua.LState) int {
+			s := checkUTF8String(L, 1)
+			substr := checkUTF8String(L, 2)
+
+			i := strings.Index(s, substr)
+			if i < 0 {
+				L.Push(notFound)
+				return 1
+			}
+
+			ret := utf8.RuneCountInString(s[:i])
+			return helper.RetInt(L, ret+base)
+		},
+		"RuneSub": func(L *lua.LState) int {
+			s := checkUTF8String(L, 1)
+
+			n := utf8.RuneCountInString(s)
+			var i, j int
+			if luaIndex {
+				// string.sub, with runes
+				i = luaPosRelat(L.OptInt(2, 1), n)
+				j = luaPosRelat(L.OptInt(3, -1), n)
+				i = max(i, 1) - 1
+			} else {
+				i = L.OptInt(2, 0)
+				j = L.OptInt(3, n)
+			}
+			i = min(max(i, 0), n)
+			j = min(max(j, 0), n)
+			if i >= j {
+				return helper.RetString(L, "")
+			}
+
+			start := runeByteOffset(s, i)
+			end := start + runeByteOffset(s[start:], j-i)
+			return helper.RetString(L, s[start:end])
+		},
+		"RuneToByteOffset": func(L *lua.LState) int {
+			s := checkUTF8String(L, 1)
+			i := L.CheckInt(2) - base
+
+			if i < 0 || i > utf8.RuneCountInString(s) {
+				L.ArgError(2, "rune offset out of range")
+			}
+
+			ret := runeByteOffset(s, i)
+			return helper.RetInt(L, ret+base)
+		},
+		"Runes": func(L *lua.LState) int {
+			s := checkUTF8String(L, 1)
+
+			if L.Get(2) != lua.LNil {
+				tbl := L.CheckTable(2)
+				rs := []rune(s)
+				fillList(tbl, len(rs), func(i int) lua.LValue {
+					return lua.LNumber(rs[i])
+				})
+				L.Push(tbl)
+				L.Push(lua.LNumber(len(rs)))
+				return 2
+			}
+
+			tbl := L.CreateTable(utf8.RuneCountInString(s), 0)
+			for _, r := range s {
+				tbl.Append(lua.LNumber(r))
+			}
+			L.Push(tbl)
+			return 1
+		},
+	}
+}
+
+// checkUTF8String is L.CheckString for valid UTF-8 only.
+func checkUTF8String(L *lua.LState, n int) string {
+	s := L.CheckString(n)
+	if !utf8.ValidString(s) {
+		L.ArgError(n, "invalid UTF-8 string")
+	}
+	return s
+}
+
+// runeByteOffset returns the byte offset of the i-th rune of s, or len(s)
+// if s has i runes.
+func runeByteOffset(s string, i int) int {
+	for j := range s {
+		if i == 0 {
+			return j
+		}
+		i--
+	}
+	return len(s)
+}
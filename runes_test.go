@@ -0,0 +1,141 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestRuneFuncs(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+
+	err := L.DoString(`
+		local strings = require("strings")
+		local s = "a你好😀b"
+
+		local t = strings.Runes(s)
+		assert(#t == 5 and t[1] == 97 and t[2] == 0x4F60 and t[4] == 0x1F600)
+		assert(#strings.Runes("") == 0)
+		assert(strings.FromRunes(t) == s)
+		assert(strings.FromRunes({}) == "")
+
+		assert(strings.RuneAt(s, 0) == 97)
+		assert(strings.RuneAt(s, 3) == 0x1F600)
+		assert(strings.RuneAt(s, 5) == nil)
+		assert(strings.RuneAt(s, -1) == nil)
+
+		assert(strings.RuneSub(s, 1, 3) == "你好")
+		assert(strings.RuneSub(s, 3) == "😀b")
+		assert(strings.RuneSub(s) == s)
+		assert(strings.RuneSub(s, 2, 2) == "")
+		assert(strings.RuneSub(s, 4, 2) == "")
+		assert(strings.RuneSub(s, -3, 100) == s)
+
+		assert(strings.RuneIndex(s, "好") == 2)
+		assert(strings.RuneIndex(s, "b") == 4)
+		assert(strings.RuneIndex(s, "x") == -1)
+		assert(strings.RuneIndex(s, "") == 0)
+
+		assert(strings.ByteToRuneOffset(s, 0) == 0)
+		assert(strings.ByteToRuneOffset(s, 4) == 2)
+		assert(strings.ByteToRuneOffset(s, 11) == 4)
+		assert(strings.ByteToRuneOffset(s, #s) == 5)
+
+		assert(strings.RuneToByteOffset(s, 0) == 0)
+		assert(strings.RuneToByteOffset(s, 2) == 4)
+		assert(strings.RuneToByteOffset(s, 4) == 11)
+		assert(strings.RuneToByteOffset(s, 5) == #s)
+
+		-- byte and rune offsets convert back and forth
+		local i = strings.Index(s, "😀")
+		assert(strings.RuneToByteOffset(s, strings.ByteToRuneOffset(s, i)) == i)
+	`)
+	require.NoError(t, err)
+}
+
+func TestRuneFuncsLuaIndex(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		LuaIndex: true,
+	})
+
+	err := L.DoString(`
+		local strings = require("strings")
+		local s = "a你好😀b"
+
+		assert(strings.RuneAt(s, 1) == 97)
+		assert(strings.RuneAt(s, 4) == 0x1F600)
+		assert(strings.RuneAt(s, -1) == 98)
+		assert(strings.RuneAt(s, -5) == 97)
+		assert(strings.RuneAt(s, 0) == nil)
+		assert(strings.RuneAt(s, 6) == nil)
+		assert(strings.RuneAt(s, -6) == nil)
+
+		-- like string.sub
+		assert(strings.RuneSub(s, 2, 3) == "你好")
+		assert(strings.RuneSub(s, 2) == "你好😀b")
+		assert(strings.RuneSub(s, -2) == "😀b")
+		assert(strings.RuneSub(s, -2, -2) == "😀")
+		assert(strings.RuneSub(s, 0) == s)
+		assert(strings.RuneSub(s, 3, 2) == "")
+		assert(strings.RuneSub(s, -100, 100) == s)
+
+		assert(strings.RuneIndex(s, "好") == 3)
+		assert(strings.RuneIndex(s, "x") == nil)
+
+		assert(strings.ByteToRuneOffset(s, 1) == 1)
+		assert(strings.ByteToRuneOffset(s, 5) == 3)
+		assert(strings.ByteToRuneOffset(s, #s + 1) == 6)
+		assert(strings.RuneToByteOffset(s, 3) == 5)
+		assert(strings.RuneToByteOffset(s, 6) == #s + 1)
+
+		-- positions plug into string.sub and RuneSub
+		local i = strings.RuneIndex(s, "好")
+		assert(strings.RuneSub(s, i, i) == "好")
+		local b = strings.RuneToByteOffset(s, i)
+		assert(s:sub(b, b + 2) == "好")
+	`)
+	require.NoError(t, err)
+}
+
+func TestRuneFuncsErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		msg  string
+	}{
+		{`strings.Runes("a\255")`, "invalid UTF-8 string"},
+		{`strings.RuneSub("\237\160\128", 0, 1)`, "invalid UTF-8 string"},
+		{`strings.RuneIndex("abc", "\255")`, "invalid UTF-8 string"},
+		{`strings.FromRunes({97, 0xD800})`, "invalid rune at index 2"},
+		{`strings.FromRunes({97, "b"})`, "invalid rune at index 2"},
+		{`strings.FromRunes({1.5})`, "invalid rune at index 1"},
+		{`strings.FromRunes({0x110000})`, "invalid rune at index 1"},
+		{`strings.ByteToRuneOffset("你好", 1)`, "byte offset not at the start of a rune"},
+		{`strings.ByteToRuneOffset("你好", 7)`, "byte offset out of range"},
+		{`strings.ByteToRuneOffset("你好", -1)`, "byte offset out of range"},
+		{`strings.RuneToByteOffset("你好", 3)`, "rune offset out of range"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(`local strings = require("strings")` + "\n" + tests[i].code)
+		require.Error(t, err, "case %d: %s", i, tests[i].code)
+		require.Contains(t, err.Error(), tests[i].msg, "case %d: %s", i, tests[i].code)
+
+		L.Close()
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// stringSeq yields the next string of a sequence, ok is false when done.
+//
+// It is the step-by-step form of Go's iter.Seq[string], which suits Lua's
+// generic for and needs no goroutine to be stopped on an early break.
+// L is the state running the iterator, which may be a coroutine.
+type stringSeq func(L *lua.LState) (s string, ok bool)
+
+// retStringSeq pushes a generic-for iterator function over seq.
+func retStringSeq(L *lua.LState, seq stringSeq) int {
+	L.Push(L.NewFunction(func(L *lua.LState) int {
+		s, ok := seq(L)
+		if !ok {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lua.LString(s))
+		return 1
+	}))
+	return 1
+}
+
+// retIndexedStringSeq pushes a generic-for iterator function over seq,
+// yielding the 1-based count and the string like ipairs.
+func retIndexedStringSeq(L *lua.LState, seq stringSeq) int {
+	i := 0
+	L.Push(L.NewFunction(func(L *lua.LState) int {
+		s, ok := seq(L)
+		if !ok {
+			L.Push(lua.LNil)
+			return 1
+		}
+		i++
+		L.Push(lua.LNumber(i))
+		L.Push(lua.LString(s))
+		return 2
+	}))
+	return 1
+}
+
+// splitSeq is like strings.SplitSeq (sepSave == 0) and
+// strings.SplitAfterSeq (sepSave == len(sep)).
+func splitSeq(s, sep string, sepSave int) stringSeq {
+	done := false
+	return func(*lua.LState) (string, bool) {
+		if done {
+			return "", false
+		}
+		if len(sep) == 0 {
+			if len(s) == 0 {
+				done = true
+				return "", false
+			}
+			_, size := utf8.DecodeRuneInString(s)
+			frag := s[:size]
+			s = s[size:]
+			return frag, true
+		}
+		i := strings.Index(s, sep)
+		if i < 0 {
+			done = true
+			return s, true
+		}
+		frag := s[:i+sepSave]
+		s = s[i+len(sep):]
+		return frag, true
+	}
+}
+
+// linesSeq is like strings.Lines.
+func linesSeq(s string) stringSeq {
+	return func(*lua.LState) (string, bool) {
+		if len(s) == 0 {
+			return "", false
+		}
+		line := s
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			line = s[:i+1]
+		}
+		s = s[len(line):]
+		return line, true
+	}
+}
+
+// fieldsSeq is like strings.FieldsSeq.
+func fieldsSeq(s string) stringSeq {
+	return fieldsFuncSeq(s, func(*lua.LState) runePredicate {
+		return func(r rune, _, _ int) bool { return unicode.IsSpace(r) }
+	})
+}
+
+// fieldsFuncSeq is like strings.FieldsFuncSeq, pred returns the predicate
+// to use for the state running the iterator.
+func fieldsFuncSeq(s string, pred func(L *lua.LState) runePredicate) stringSeq {
+	i := 0
+	return func(L *lua.LState) (string, bool) {
+		f := pred(L)
+		start := -1
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if f(r, i, size) {
+				if start >= 0 {
+					field := s[start:i]
+					i += size
+					return field, true
+				}
+			} else if start < 0 {
+				start = i
+			}
+			i += size
+		}
+		if start >= 0 {
+			return s[start:], true
+		}
+		return "", false
+	}
+}
+
+// checkFieldsFuncSeq returns the fieldsFuncSeq of s split by the rune
+// class name or the function at position 2 of the stack.
+func checkFieldsFuncSeq(L *lua.LState, name, s string) stringSeq {
+	if L.Get(2).Type() == lua.LTString {
+		f := checkRuneClass(L, 2)
+		return fieldsFuncSeq(s, func(*lua.LState) runePredicate {
+			return f
+		})
+	}
+
+	// the iterator may be resumed from another coroutine
+	fn := L.CheckFunction(2)
+	return fieldsFuncSeq(s, func(L *lua.LState) runePredicate {
+		return luaRunePredicate(L, name, fn, s)
+	})
+}
@@ -5,24 +5,126 @@
 package strings
 
 import (
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	helper "github.com/chai2010/glua-helper"
 	lua "github.com/yuin/gopher-lua"
 )
 
+// Options configures the module returned by NewLoader, the zero value
+// configures the module of Loader.
+type Options struct {
+	// StringMethods installs the functions as methods of Lua strings
+	// when the module is loaded, see InstallStringMethods.
+	StringMethods bool
+
+	// MethodConflict applies to StringMethods.
+	MethodConflict MethodConflict
+
+	// LuaIndex makes Index, LastIndex and the other index functions
+	// return 1-based positions that fit string.sub, and nil instead of
+	// -1 when not found. The rune offset functions take and return
+	// 1-based positions too, and so do FindStringIndex of the
+	// expressions compiled by the regexp submodule and FindAll of the
+	// matchers built by NewMatcher. Offsets passed to rune callbacks
+	// stay 0-based.
+	LuaIndex bool
+
+	// CaseLocale makes ToLower, ToTitle, ToUpper and TitleCase use the
+	// special case mapping of a locale, "turkish" or "azeri", instead
+	// of the default Unicode mapping.
+	CaseLocale string
+}
+
 func Preload(L *lua.LState) {
-	L.PreloadModule("strings", Loader)
+	PreloadWithOptions(L, Options{})
+}
+
+func PreloadWithOptions(L *lua.LState, opts Options) {
+	L.PreloadModule("strings", NewLoader(opts))
+	L.PreloadModule("strings.unicode", UnicodeLoader)
+	L.PreloadModule("strings.utf8", UTF8Loader)
+	L.PreloadModule("strings.regexp", newRegexpLoader(opts.LuaIndex))
 }
 
 func Loader(L *lua.LState) int {
+	return loadModule(L, Options{})
+}
+
+func NewLoader(opts Options) lua.LGFunction {
+	return func(L *lua.LState) int {
+		return loadModule(L, opts)
+	}
+}
+
+func loadModule(L *lua.LState, opts Options) int {
+	if _, ok := specialCases[opts.CaseLocale]; opts.CaseLocale != "" && !ok {
+		L.RaiseError("strings: unknown casing locale '%s'", opts.CaseLocale)
+	}
+	funcs := moduleFuncs(opts)
+
 	mod := L.NewTable()
-	L.SetFuncs(mod, stringsFuncs)
+	L.SetFuncs(mod, funcs)
+	registerBuilderType(L, mod)
+	registerReaderType(L, mod)
+	registerReplacerType(L, mod)
+	registerMatcherType(L, mod, opts.LuaIndex)
+	setSubmodule(L, mod, "unicode", UnicodeLoader)
+	setSubmodule(L, mod, "utf8", UTF8Loader)
+	setSubmodule(L, mod, "regexp", newRegexpLoader(opts.LuaIndex))
+
+	if opts.StringMethods {
+		installStringMethods(L, funcs, opts.MethodConflict)
+	}
+
 	L.Push(mod)
 	return 1
 }
 
+// moduleFuncs returns the functions of the module configured by opts.
+func moduleFuncs(opts Options) map[string]lua.LGFunction {
+	funcs := make(map[string]lua.LGFunction, len(stringsFuncs))
+	for _, m := range []map[string]lua.LGFunction{
+		stringsFuncs,
+		specialCaseFuncs,
+		foldFuncs,
+		patternFuncs,
+	} {
+		for name, fn := range m {
+			if opts.LuaIndex && indexFuncs[name] {
+				fn = luaIndex(fn)
+			}
+			funcs[name] = fn
+		}
+	}
+	for name, fn := range runeFuncs(opts.LuaIndex) {
+		funcs[name] = fn
+	}
+	if c, ok := specialCases[opts.CaseLocale]; ok {
+		for name, fn := range localeCaseFuncs(c) {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+// setSubmodule sets mod[name] to the module returned by loader.
+func setSubmodule(L *lua.LState, mod *lua.LTable, name string, loader lua.LGFunction) {
+	L.Push(L.NewFunction(loader))
+	L.Call(0, 1)
+	L.SetField(mod, name, L.Get(-1))
+	L.Pop(1)
+}
+
 var stringsFuncs = map[string]lua.LGFunction{
+	"Clone": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := strings.Clone(s)
+		return helper.RetString(L, ret)
+	},
 	"Compare": func(L *lua.LState) int {
 		a := L.CheckString(1)
 		b := L.CheckString(2)
@@ -44,11 +146,18 @@ var stringsFuncs = map[string]lua.LGFunction{
 		ret := strings.ContainsAny(s, chars)
 		return helper.RetBool(L, ret)
 	},
+	"ContainsFunc": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		f := checkRunePredicate(L, 2, "ContainsFunc", s)
+
+		ret := indexFunc(s, f) >= 0
+		return helper.RetBool(L, ret)
+	},
 	"ContainsRune": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		r := L.CheckInt(2)
+		r := checkRune(L, 2)
 
-		ret := strings.ContainsRune(s, rune(r))
+		ret := strings.ContainsRune(s, r)
 		return helper.RetBool(L, ret)
 	},
 	"Count": func(L *lua.LState) int {
@@ -58,6 +167,27 @@ var stringsFuncs = map[string]lua.LGFunction{
 		ret := strings.Count(s, substr)
 		return helper.RetInt(L, ret)
 	},
+	"Cut": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		sep := L.CheckString(2)
+
+		before, after, found := strings.Cut(s, sep)
+		return helper.Return(L, before, after, found)
+	},
+	"CutPrefix": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		prefix := L.CheckString(2)
+
+		after, found := strings.CutPrefix(s, prefix)
+		return helper.Return(L, after, found)
+	},
+	"CutSuffix": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		suffix := L.CheckString(2)
+
+		before, found := strings.CutSuffix(s, suffix)
+		return helper.Return(L, before, found)
+	},
 	"EqualFold": func(L *lua.LState) int {
 		s := L.CheckString(1)
 		t := L.CheckString(2)
@@ -69,18 +199,38 @@ var stringsFuncs = map[string]lua.LGFunction{
 		s := L.CheckString(1)
 
 		ret := strings.Fields(s)
-		return helper.RetStringList(L, ret)
+		return retStringList(L, 2, ret)
 	},
 	"FieldsFunc": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		fn := L.CheckFunction(2)
+		f := checkRunePredicate(L, 2, "FieldsFunc", s)
+
+		ret := fieldsFunc(s, f)
+		return retStringList(L, 3, ret)
+	},
+	"FieldsFuncIter": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := checkFieldsFuncSeq(L, "FieldsFuncIter", s)
+		return retIndexedStringSeq(L, ret)
+	},
+	"FieldsFuncSeq": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := checkFieldsFuncSeq(L, "FieldsFuncSeq", s)
+		return retStringSeq(L, ret)
+	},
+	"FieldsIter": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := fieldsSeq(s)
+		return retIndexedStringSeq(L, ret)
+	},
+	"FieldsSeq": func(L *lua.LState) int {
+		s := L.CheckString(1)
 
-		ret := strings.FieldsFunc(s, func(r rune) bool {
-			return callFunc_Rune_ret_Bool(
-				L, fn, lua.LNumber(r),
-			)
-		})
-		return helper.RetStringList(L, ret)
+		ret := fieldsSeq(s)
+		return retStringSeq(L, ret)
 	},
 	"HasPrefix": func(L *lua.LState) int {
 		s := L.CheckString(1)
@@ -112,40 +262,55 @@ var stringsFuncs = map[string]lua.LGFunction{
 	},
 	"IndexByte": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		t := L.CheckInt(2)
+		t := checkByte(L, 2)
 
-		ret := strings.IndexByte(s, byte(t))
+		ret := strings.IndexByte(s, t)
 		return helper.RetInt(L, ret)
 	},
 	"IndexFunc": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		fn := L.CheckFunction(2)
+		f := checkRunePredicate(L, 2, "IndexFunc", s)
 
-		ret := strings.IndexFunc(s, func(r rune) bool {
-			return callFunc_Rune_ret_Bool(
-				L, fn, lua.LNumber(r),
-			)
-		})
+		ret := indexFunc(s, f)
 		return helper.RetInt(L, ret)
 	},
 	"IndexRune": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		t := L.CheckInt(2)
+		t := checkRune(L, 2)
 
-		ret := strings.IndexRune(s, rune(t))
+		ret := strings.IndexRune(s, t)
 		return helper.RetInt(L, ret)
 	},
 	"Join": func(L *lua.LState) int {
+		// Join(sep, ...) joins its arguments
+		if L.Get(1).Type() == lua.LTString {
+			sep := L.CheckString(1)
+
+			strs := make([]string, 0, L.GetTop()-1)
+			for i := 2; i <= L.GetTop(); i++ {
+				str, ok := toJoinString(L, L.Get(i))
+				if !ok {
+					L.ArgError(i, "string expected, got "+L.Get(i).Type().String())
+				}
+				strs = append(strs, str)
+			}
+
+			ret := strings.Join(strs, sep)
+			return helper.RetString(L, ret)
+		}
+
 		tbl := L.CheckTable(1)
 		sep := L.CheckString(2)
 
+		// walk the array part in order, like table.concat
 		strs := make([]string, 0, tbl.Len())
-
-		tbl.ForEach(func(_, value lua.LValue) {
-			if str, ok := value.(lua.LString); ok {
-				strs = append(strs, string(str))
+		for i := 1; i <= tbl.Len(); i++ {
+			str, ok := toJoinString(L, tbl.RawGetInt(i))
+			if !ok {
+				L.RaiseError("invalid value (at index %d) in table for 'Join'", i)
 			}
-		})
+			strs = append(strs, str)
+		}
 
 		ret := strings.Join(strs, sep)
 		return helper.RetString(L, ret)
@@ -166,35 +331,45 @@ var stringsFuncs = map[string]lua.LGFunction{
 	},
 	"LastIndexByte": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		t := L.CheckInt(2)
+		t := checkByte(L, 2)
 
-		ret := strings.LastIndexByte(s, byte(t))
+		ret := strings.LastIndexByte(s, t)
 		return helper.RetInt(L, ret)
 	},
 	"LastIndexFunc": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		fn := L.CheckFunction(2)
+		f := checkRunePredicate(L, 2, "LastIndexFunc", s)
 
-		ret := strings.LastIndexFunc(s, func(r rune) bool {
-			return callFunc_Rune_ret_Bool(
-				L, fn, lua.LNumber(r),
-			)
-		})
+		ret := lastIndexFunc(s, f)
 		return helper.RetInt(L, ret)
 	},
+	"Lines": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := linesSeq(s)
+		return retStringSeq(L, ret)
+	},
+	"LinesIter": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := linesSeq(s)
+		return retIndexedStringSeq(L, ret)
+	},
 	"Map": func(L *lua.LState) int {
 		fn := L.CheckFunction(1)
 		s := L.CheckString(2)
 
-		ret := strings.Map(
-			func(r rune) rune {
-				return callFunc_Rune_ret_Rune(
-					L, fn, lua.LNumber(r),
-				)
-			},
-			s,
-		)
-		return helper.RetString(L, ret)
+		// like strings.Map, but a callback result may be more than one rune
+		var b strings.Builder
+		b.Grow(len(s))
+		for i := 0; i < len(s); {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteString(callFunc_Rune_ret_String(
+				L, "Map", fn, r, s[i:i+size], i,
+			))
+			i += size
+		}
+		return helper.RetString(L, b.String())
 	},
 	"Repeat": func(L *lua.LState) int {
 		s := L.CheckString(1)
@@ -212,45 +387,95 @@ var stringsFuncs = map[string]lua.LGFunction{
 		ret := strings.Replace(s, t, z, n)
 		return helper.RetString(L, ret)
 	},
+	"ReplaceAll": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+		z := L.CheckString(3)
+
+		ret := strings.ReplaceAll(s, t, z)
+		return helper.RetString(L, ret)
+	},
 	"Split": func(L *lua.LState) int {
 		s := L.CheckString(1)
 		t := L.CheckString(2)
 
 		ret := strings.Split(s, t)
-		return helper.RetStringList(L, ret)
+		return retStringList(L, 3, ret)
 	},
 	"SplitAfter": func(L *lua.LState) int {
 		s := L.CheckString(1)
 		t := L.CheckString(2)
 
 		ret := strings.SplitAfter(s, t)
-		return helper.RetStringList(L, ret)
+		return retStringList(L, 3, ret)
+	},
+	"SplitAfterIter": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+
+		ret := splitSeq(s, t, len(t))
+		return retIndexedStringSeq(L, ret)
 	},
 	"SplitAfterN": func(L *lua.LState) int {
 		s := L.CheckString(1)
 		t := L.CheckString(2)
 		n := L.CheckInt(3)
 
-		if n == 0 {
+		if n == 0 && L.Get(4) == lua.LNil {
 			L.Push(lua.LNil)
 			return 1
 		}
 
 		ret := strings.SplitAfterN(s, t, n)
-		return helper.RetStringList(L, ret)
+		return retStringList(L, 4, ret)
+	},
+	"SplitAfterNv": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+		n := checkSplitCount(L, 3)
+
+		return pushSplitN(L, s, t, len(t), n)
+	},
+	"SplitAfterSeq": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+
+		ret := splitSeq(s, t, len(t))
+		return retStringSeq(L, ret)
+	},
+	"SplitIter": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+
+		ret := splitSeq(s, t, 0)
+		return retIndexedStringSeq(L, ret)
 	},
 	"SplitN": func(L *lua.LState) int {
 		s := L.CheckString(1)
 		t := L.CheckString(2)
 		n := L.CheckInt(3)
 
-		if n == 0 {
+		if n == 0 && L.Get(4) == lua.LNil {
 			L.Push(lua.LNil)
 			return 1
 		}
 
 		ret := strings.SplitN(s, t, n)
-		return helper.RetStringList(L, ret)
+		return retStringList(L, 4, ret)
+	},
+	"SplitNv": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+		n := checkSplitCount(L, 3)
+
+		return pushSplitN(L, s, t, 0, n)
+	},
+	"SplitSeq": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		t := L.CheckString(2)
+
+		ret := splitSeq(s, t, 0)
+		return retStringSeq(L, ret)
 	},
 	"Title": func(L *lua.LState) int {
 		s := L.CheckString(1)
@@ -258,6 +483,7 @@ var stringsFuncs = map[string]lua.LGFunction{
 		ret := strings.Title(s)
 		return helper.RetString(L, ret)
 	},
+	"TitleCase": newTitleCase(nil),
 	"ToLower": func(L *lua.LState) int {
 		s := L.CheckString(1)
 
@@ -276,6 +502,13 @@ var stringsFuncs = map[string]lua.LGFunction{
 		ret := strings.ToUpper(s)
 		return helper.RetString(L, ret)
 	},
+	"ToValidUTF8": func(L *lua.LState) int {
+		s := L.CheckString(1)
+		replacement := L.CheckString(2)
+
+		ret := strings.ToValidUTF8(s, replacement)
+		return helper.RetString(L, ret)
+	},
 	"Trim": func(L *lua.LState) int {
 		s := L.CheckString(1)
 		cutset := L.CheckString(2)
@@ -285,13 +518,9 @@ var stringsFuncs = map[string]lua.LGFunction{
 	},
 	"TrimFunc": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		fn := L.CheckFunction(2)
+		f := checkRunePredicate(L, 2, "TrimFunc", s)
 
-		ret := strings.TrimFunc(s, func(r rune) bool {
-			return callFunc_Rune_ret_Bool(
-				L, fn, lua.LNumber(r),
-			)
-		})
+		ret := trimFunc(s, f)
 		return helper.RetString(L, ret)
 	},
 	"TrimLeft": func(L *lua.LState) int {
@@ -303,13 +532,9 @@ var stringsFuncs = map[string]lua.LGFunction{
 	},
 	"TrimLeftFunc": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		fn := L.CheckFunction(2)
+		f := checkRunePredicate(L, 2, "TrimLeftFunc", s)
 
-		ret := strings.TrimLeftFunc(s, func(r rune) bool {
-			return callFunc_Rune_ret_Bool(
-				L, fn, lua.LNumber(r),
-			)
-		})
+		ret := trimLeftFunc(s, f)
 		return helper.RetString(L, ret)
 	},
 	"TrimPrefix": func(L *lua.LState) int {
@@ -328,13 +553,9 @@ var stringsFuncs = map[string]lua.LGFunction{
 	},
 	"TrimRightFunc": func(L *lua.LState) int {
 		s := L.CheckString(1)
-		fn := L.CheckFunction(2)
+		f := checkRunePredicate(L, 2, "TrimRightFunc", s)
 
-		ret := strings.TrimRightFunc(s, func(r rune) bool {
-			return callFunc_Rune_ret_Bool(
-				L, fn, lua.LNumber(r),
-			)
-		})
+		ret := trimRightFunc(s, f)
 		return helper.RetString(L, ret)
 	},
 	"TrimSpace": func(L *lua.LState) int {
@@ -352,26 +573,238 @@ var stringsFuncs = map[string]lua.LGFunction{
 	},
 }
 
-// func(rune) bool
-func callFunc_Rune_ret_Bool(L *lua.LState, lf *lua.LFunction, args ...lua.LValue) bool {
-	err := L.CallByParam(lua.P{Protect: true, Fn: lf, NRet: 1}, args...)
+// func(r rune, char string, offset int) bool
+//
+// The result of the callback is taken with Lua truthiness.
+func callFunc_Rune_ret_Bool(L *lua.LState, name string, lf *lua.LFunction, r rune, char string, offset int) bool {
+	err := L.CallByParam(lua.P{Protect: true, Fn: lf, NRet: 1},
+		lua.LNumber(r), lua.LString(char), lua.LNumber(offset),
+	)
 	if err != nil {
-		panic(err)
+		raiseCallbackError(L, name, r, err.Error())
 	}
 	defer L.Pop(1)
 
-	ret := L.CheckBool(-1)
+	ret := lua.LVAsBool(L.Get(-1))
 	return ret
 }
 
-// func(rune) rune
-func callFunc_Rune_ret_Rune(L *lua.LState, lf *lua.LFunction, args ...lua.LValue) rune {
+// func(r rune, char string, offset int) string
+//
+// The callback returns a codepoint, a string, or nil, false or a negative
+// number to drop the rune. Like checkRune, other numbers that are not a
+// valid rune are rejected.
+func callFunc_Rune_ret_String(L *lua.LState, name string, lf *lua.LFunction, r rune, char string, offset int) string {
+	err := L.CallByParam(lua.P{Protect: true, Fn: lf, NRet: 1},
+		lua.LNumber(r), lua.LString(char), lua.LNumber(offset),
+	)
+	if err != nil {
+		raiseCallbackError(L, name, r, err.Error())
+	}
+	defer L.Pop(1)
+
+	switch ret := L.Get(-1).(type) {
+	case lua.LNumber:
+		if ret < 0 {
+			return ""
+		}
+		if ret > utf8.MaxRune || ret != lua.LNumber(int(ret)) || !utf8.ValidRune(rune(ret)) {
+			raiseCallbackError(L, name, r, "invalid rune result "+ret.String())
+		}
+		return string(rune(ret))
+	case lua.LString:
+		return string(ret)
+	case *lua.LNilType:
+		return ""
+	case lua.LBool:
+		if !ret {
+			return ""
+		}
+	}
+	raiseCallbackError(L, name, r, "number or string expected as result, got "+L.Get(-1).Type().String())
+	return ""
+}
+
+// func(args ...) string
+//
+// The callback returns the replacement of match, see matchReplacement.
+// prefix names the function in the error messages.
+func callFunc_Match_ret_String(L *lua.LState, prefix string, lf *lua.LFunction, match string, args ...lua.LValue) string {
 	err := L.CallByParam(lua.P{Protect: true, Fn: lf, NRet: 1}, args...)
 	if err != nil {
-		panic(err)
+		L.RaiseError("%s: callback failed on match %q: %s", prefix, match, err.Error())
 	}
 	defer L.Pop(1)
 
-	ret := L.CheckInt(-1)
-	return rune(ret)
+	ret, ok := matchReplacement(L.Get(-1), match)
+	if !ok {
+		L.RaiseError("%s: callback failed on match %q: string expected as result, got %s",
+			prefix, match, L.Get(-1).Type().String())
+	}
+	return ret
+}
+
+// matchReplacement converts the replacement value of match like
+// string.gsub: a string or number is the text, nil or false keeps the
+// match. ok is false for any other value.
+func matchReplacement(value lua.LValue, match string) (ret string, ok bool) {
+	switch value := value.(type) {
+	case lua.LString, lua.LNumber:
+		return value.String(), true
+	case *lua.LNilType:
+		return match, true
+	case lua.LBool:
+		if !value {
+			return match, true
+		}
+	}
+	return "", false
+}
+
+// raiseCallbackError raises the failure of the callback passed to the
+// strings function name as a Lua error, so that pcall catches it.
+func raiseCallbackError(L *lua.LState, name string, r rune, msg string) {
+	L.RaiseError("strings.%s: callback failed on rune %q (U+%04X): %s", name, r, r, msg)
+}
+
+// retStringList is helper.RetStringList, but if a table is given at
+// position n, its sequence is replaced by vs and it is returned with the
+// count, so that callers can reuse one table.
+func retStringList(L *lua.LState, n int, vs []string) int {
+	if L.Get(n) == lua.LNil {
+		return helper.RetStringList(L, vs)
+	}
+	tbl := L.CheckTable(n)
+
+	fillList(tbl, len(vs), func(i int) lua.LValue {
+		return lua.LString(vs[i])
+	})
+	L.Push(tbl)
+	L.Push(lua.LNumber(len(vs)))
+	return 2
+}
+
+// maxSplitCount bounds the count of the vararg split functions, well
+// below the default size of the gopher-lua stack.
+const maxSplitCount = 256
+
+// checkSplitCount checks the count of the vararg split functions. Unlike
+// SplitN, a negative count is rejected and a count over maxSplitCount
+// too: the pieces are returned on the Lua stack, which is not meant for
+// an unbounded number of values and overflows with no clear error.
+func checkSplitCount(L *lua.LState, n int) int {
+	count := L.CheckInt(n)
+	if count < 0 {
+		L.ArgError(n, "negative count")
+	}
+	if count > maxSplitCount {
+		L.ArgError(n, "too many results, count over "+strconv.Itoa(maxSplitCount))
+	}
+	return count
+}
+
+// pushSplitN pushes the pieces of strings.SplitN(s, sep, n) (or
+// SplitAfterN, if sepSave is len(sep)) onto the stack without building
+// a slice, and returns how many were pushed.
+func pushSplitN(L *lua.LState, s, sep string, sepSave, n int) int {
+	count := 0
+	if sep == "" {
+		for count < n-1 && s != "" {
+			_, size := utf8.DecodeRuneInString(s)
+			L.Push(lua.LString(s[:size]))
+			s = s[size:]
+			count++
+		}
+		if count < n && s != "" {
+			L.Push(lua.LString(s))
+			count++
+		}
+		return count
+	}
+
+	if n == 0 {
+		return 0
+	}
+	for count < n-1 {
+		i := strings.Index(s, sep)
+		if i < 0 {
+			break
+		}
+		L.Push(lua.LString(s[:i+sepSave]))
+		s = s[i+len(sep):]
+		count++
+	}
+	L.Push(lua.LString(s))
+	return count + 1
+}
+
+// fillList sets tbl[1..n] to value(0..n-1), and the elements after them
+// to nil. Keys outside the sequence are left as they are.
+func fillList(tbl *lua.LTable, n int, value func(i int) lua.LValue) {
+	end := tbl.Len()
+	for i := 0; i < n; i++ {
+		tbl.RawSetInt(i+1, value(i))
+	}
+	for i := n + 1; i <= end; i++ {
+		tbl.RawSetInt(i, lua.LNil)
+	}
+}
+
+// toJoinString converts v to a string like table.concat does, with
+// tables and userdata converted by their __tostring metamethod.
+func toJoinString(L *lua.LState, v lua.LValue) (string, bool) {
+	switch v := v.(type) {
+	case lua.LString:
+		return string(v), true
+	case lua.LNumber:
+		return v.String(), true
+	case *lua.LTable, *lua.LUserData:
+		if L.GetMetaField(v, "__tostring") == lua.LNil {
+			break
+		}
+		if ret, ok := L.CallMeta(v, "__tostring").(lua.LString); ok {
+			return string(ret), true
+		}
+		L.RaiseError("'__tostring' must return a string")
+	}
+	return "", false
+}
+
+// checkRune returns the rune at position n, given as a codepoint or as a
+// string of one character.
+func checkRune(L *lua.LState, n int) rune {
+	switch v := L.Get(n).(type) {
+	case lua.LNumber:
+		if v < 0 || v > utf8.MaxRune || v != lua.LNumber(int(v)) || !utf8.ValidRune(rune(v)) {
+			L.ArgError(n, "invalid rune "+v.String())
+		}
+		return rune(v)
+	case lua.LString:
+		r, size := utf8.DecodeRuneInString(string(v))
+		if size == 0 || size != len(v) || r == utf8.RuneError && size == 1 {
+			L.ArgError(n, "single character string expected")
+		}
+		return r
+	}
+	L.TypeError(n, lua.LTNumber)
+	return 0
+}
+
+// checkByte returns the byte at position n, given as a number in 0-255
+// or as a string of one byte.
+func checkByte(L *lua.LState, n int) byte {
+	switch v := L.Get(n).(type) {
+	case lua.LNumber:
+		if v < 0 || v > 0xFF || v != lua.LNumber(int(v)) {
+			L.ArgError(n, "byte out of range")
+		}
+		return byte(v)
+	case lua.LString:
+		if len(v) != 1 {
+			L.ArgError(n, "single byte string expected")
+		}
+		return v[0]
+	}
+	L.TypeError(n, lua.LTNumber)
+	return 0
 }
@@ -100,6 +100,90 @@ func callLuaFunc[T any](
 	return result
 }
 
+func callLuaFuncN(
+	t *testing.T, L *lua.LState,
+	funcName string, args []lua.LValue, nret int,
+) []lua.LValue {
+	t.Helper()
+
+	L.Push(L.GetGlobal(funcName))
+	for _, arg := range args {
+		L.Push(arg)
+	}
+
+	// execute function with len(args) arguments and nret return values
+	L.Call(len(args), nret)
+	result := make([]lua.LValue, nret)
+	for i := range result {
+		result[i] = L.Get(i - nret)
+	}
+	L.Pop(nret)
+
+	return result
+}
+
+func callLuaSeq(
+	t *testing.T, L *lua.LState,
+	funcName string, args []lua.LValue,
+) []string {
+	t.Helper()
+
+	// the function returns an iterator for the generic for
+	L.Push(L.GetGlobal(funcName))
+	for _, arg := range args {
+		L.Push(arg)
+	}
+	L.Call(len(args), 1)
+	next := L.CheckFunction(-1)
+	L.Pop(1)
+
+	result := []string{}
+	for {
+		L.Push(next)
+		L.Call(0, 1)
+		value := L.Get(-1)
+		L.Pop(1)
+		if value == lua.LNil {
+			break
+		}
+		result = append(result, value.String())
+	}
+
+	return result
+}
+
+func TestClone(t *testing.T) {
+	const luaFuncName = "Clone"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s string
+	}{
+		{""},
+		{"hello"},
+		{"你好世界"},
+		{"a\u0000b"},
+		{"\xff\xfe"},
+	}
+
+	for i := range tests {
+		expected := strings.Clone(tests[i].s)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+		}
+		got := callLuaFunc(t, L, luaFuncName, args, func(L *lua.LState, idx int) string {
+			return L.ToString(idx)
+		})
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %q but Go returned %q (string: %q)",
+			i, got, expected, tests[i].s)
+	}
+}
+
 func TestCompare(t *testing.T) {
 	const luaFuncName = "Compare"
 
@@ -222,6 +306,71 @@ func TestContainsAny(t *testing.T) {
 	}
 }
 
+func TestContainsFunc(t *testing.T) {
+	tests := []struct {
+		s       string
+		luaFunc string
+		goFunc  func(rune) bool
+	}{
+		{
+			s: "",
+			luaFunc: `
+				function(r)
+					return true
+				end
+			`,
+			goFunc: func(r rune) bool { return true },
+		},
+		{
+			s: "hello123",
+			luaFunc: `
+				function(r)
+					return r >= string.byte("0") and r <= string.byte("9")
+				end
+			`,
+			goFunc: func(r rune) bool { return r >= '0' && r <= '9' },
+		},
+		{
+			s: "hello",
+			luaFunc: `
+				function(r)
+					return r >= string.byte("0") and r <= string.byte("9")
+				end
+			`,
+			goFunc: func(r rune) bool { return r >= '0' && r <= '9' },
+		},
+		{
+			s: "abc世界",
+			luaFunc: `
+				function(r)
+					return r == 0x754C
+				end
+			`,
+			goFunc: func(r rune) bool { return r == '界' },
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/string=%q", i, tt.s), func(t *testing.T) {
+			testL := setupLuaFuncTest(t, "ContainsFunc", tt.luaFunc)
+			defer testL.Close()
+
+			expected := strings.ContainsFunc(tt.s, tt.goFunc)
+
+			testL.Push(testL.GetGlobal("test_ContainsFunc"))
+			testL.Push(lua.LString(tt.s))
+			testL.Call(1, 1)
+
+			got := testL.ToBool(-1)
+			testL.Pop(1)
+
+			require.Equal(t, expected, got,
+				"case %d: Lua returned %v but Go returned %v (string: %q, func: %q)",
+				i, got, expected, tt.s, tt.luaFunc)
+		})
+	}
+}
+
 func TestContainsRune(t *testing.T) {
 	const luaFuncName = "ContainsRune"
 
@@ -247,9 +396,6 @@ func TestContainsRune(t *testing.T) {
 		{"h3llo", '3'},
 		{"hello", '9'},
 		{"hello\U0010FFFF", 0x10FFFF},
-		{"hello", 0xD800},
-		{"hello", -1},
-		{"hello", 0x110000},
 	}
 
 	for i := range tests {
@@ -311,6 +457,116 @@ func TestCount(t *testing.T) {
 	}
 }
 
+func TestCut(t *testing.T) {
+	const luaFuncName = "Cut"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s   string
+		sep string
+	}{
+		{"", ""},
+		{"", "="},
+		{"key=value", "="},
+		{"key=value=more", "="},
+		{"novalue", "="},
+		{"=value", "="},
+		{"key=", "="},
+		{"你好:世界", ":"},
+		{"a::b", "::"},
+		{"hello", ""},
+	}
+
+	for i := range tests {
+		before, after, found := strings.Cut(tests[i].s, tests[i].sep)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].sep),
+		}
+		got := callLuaFuncN(t, L, luaFuncName, args, 3)
+
+		expected := []lua.LValue{lua.LString(before), lua.LString(after), lua.LBool(found)}
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (string: %q, sep: %q)",
+			i, got, expected, tests[i].s, tests[i].sep)
+	}
+}
+
+func TestCutPrefix(t *testing.T) {
+	const luaFuncName = "CutPrefix"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s      string
+		prefix string
+	}{
+		{"", ""},
+		{"hello", ""},
+		{"", "hello"},
+		{"hello", "he"},
+		{"hello", "hello"},
+		{"hello", "world"},
+		{"你好世界", "你好"},
+		{"--flag", "--"},
+	}
+
+	for i := range tests {
+		after, found := strings.CutPrefix(tests[i].s, tests[i].prefix)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].prefix),
+		}
+		got := callLuaFuncN(t, L, luaFuncName, args, 2)
+
+		expected := []lua.LValue{lua.LString(after), lua.LBool(found)}
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (string: %q, prefix: %q)",
+			i, got, expected, tests[i].s, tests[i].prefix)
+	}
+}
+
+func TestCutSuffix(t *testing.T) {
+	const luaFuncName = "CutSuffix"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s      string
+		suffix string
+	}{
+		{"", ""},
+		{"hello", ""},
+		{"", "hello"},
+		{"hello", "lo"},
+		{"hello", "hello"},
+		{"hello", "world"},
+		{"你好世界", "世界"},
+		{"main.lua", ".lua"},
+	}
+
+	for i := range tests {
+		before, found := strings.CutSuffix(tests[i].s, tests[i].suffix)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].suffix),
+		}
+		got := callLuaFuncN(t, L, luaFuncName, args, 2)
+
+		expected := []lua.LValue{lua.LString(before), lua.LBool(found)}
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (string: %q, suffix: %q)",
+			i, got, expected, tests[i].s, tests[i].suffix)
+	}
+}
+
 func TestEqualFold(t *testing.T) {
 	const luaFuncName = "EqualFold"
 
@@ -599,30 +855,349 @@ func TestFieldsFunc(t *testing.T) {
 		},
 	}
 
-	for i, tt := range tests {
-		t.Run(fmt.Sprintf("case=%d/string=%q", i, tt.s), func(t *testing.T) {
-			testL := setupLuaFuncTest(t, "FieldsFunc", tt.luaFunc)
-			defer testL.Close()
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/string=%q", i, tt.s), func(t *testing.T) {
+			testL := setupLuaFuncTest(t, "FieldsFunc", tt.luaFunc)
+			defer testL.Close()
+
+			expected := strings.FieldsFunc(tt.s, tt.goFunc)
+
+			testL.Push(testL.GetGlobal("test_FieldsFunc"))
+			testL.Push(lua.LString(tt.s))
+			testL.Call(1, 1)
+
+			resultTable := testL.CheckTable(-1)
+			got := make([]string, 0, resultTable.Len())
+			resultTable.ForEach(func(_, value lua.LValue) {
+				got = append(got, value.String())
+			})
+
+			require.Equal(t, expected, got,
+				"case %d: Lua returned %v but Go returned %v (string: %q, func: %q)",
+				i, got, expected, tt.s, tt.luaFunc)
+		})
+	}
+}
+
+func TestFieldsFuncSeq(t *testing.T) {
+	tests := []struct {
+		s       string
+		luaFunc string
+		goFunc  func(rune) bool
+	}{
+		{
+			s: "",
+			luaFunc: `
+				function(r)
+					return true
+				end
+			`,
+			goFunc: func(r rune) bool { return true },
+		},
+		{
+			s: "abc,def,,ghi,",
+			luaFunc: `
+				function(r)
+					return r == string.byte(",")
+				end
+			`,
+			goFunc: func(r rune) bool { return r == ',' },
+		},
+		{
+			s: "12ab34cd56",
+			luaFunc: `
+				function(r)
+					return r >= string.byte("0") and r <= string.byte("9")
+				end
+			`,
+			goFunc: func(r rune) bool { return r >= '0' && r <= '9' },
+		},
+		{
+			s: "one☺two☺three",
+			luaFunc: `
+				function(r)
+					return r == 0x263A
+				end
+			`,
+			goFunc: func(r rune) bool { return r == '☺' },
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/string=%q", i, tt.s), func(t *testing.T) {
+			testL := setupLuaFuncTest(t, "FieldsFuncSeq", tt.luaFunc)
+			defer testL.Close()
+
+			// FieldsFuncSeq yields the same fields as FieldsFunc
+			expected := strings.FieldsFunc(tt.s, tt.goFunc)
+
+			args := []lua.LValue{
+				lua.LString(tt.s),
+			}
+			got := callLuaSeq(t, testL, "test_FieldsFuncSeq", args)
+
+			require.Equal(t, expected, got,
+				"case %d: Lua returned %v but Go returned %v (string: %q, func: %q)",
+				i, got, expected, tt.s, tt.luaFunc)
+		})
+	}
+}
+
+func TestFieldsSeq(t *testing.T) {
+	const luaFuncName = "FieldsSeq"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s string
+	}{
+		{""},
+		{" "},
+		{"hello"},
+		{"  hello  world  "},
+		{"a\tb\nc\rd"},
+		{" hello 世界　"},
+		{"αβγ δεζ"},
+	}
+
+	for i := range tests {
+		// FieldsSeq yields the same fields as Fields
+		expected := strings.Fields(tests[i].s)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+		}
+		got := callLuaSeq(t, L, luaFuncName, args)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %q but Go returned %q (string: %q)",
+			i, got, expected, tests[i].s)
+	}
+}
+
+func TestFuncCallbackArgs(t *testing.T) {
+	tests := []struct {
+		fn       string
+		s        string
+		result   lua.LValue
+		expected string
+	}{
+		{"IndexFunc", "a世b", lua.LNumber(-1), "97:a:0 19990:世:1 98:b:4"},
+		{"LastIndexFunc", "a世b", lua.LNumber(-1), "98:b:4 19990:世:1 97:a:0"},
+		{"ContainsFunc", "a世", lua.LFalse, "97:a:0 19990:世:1"},
+		{"TrimLeftFunc", "xxa", lua.LString("xxa"), "120:x:0"},
+		{"TrimRightFunc", "ax", lua.LString("ax"), "120:x:1"},
+		{"TrimFunc", "a世b", lua.LString("a世b"), "97:a:0 98:b:4"},
+		{"Map", "a世", lua.LString(""), "97:a:0 19990:世:1"},
+		{"FieldsFunc", "\xffa", nil, "65533:\xff:0 97:a:1"},
+		{"FieldsFuncSeq", "a世", nil, "97:a:0 19990:世:1"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/func=%s", i, tt.fn), func(t *testing.T) {
+			L := lua.NewState()
+			defer L.Close()
+
+			L.PreloadModule("strings", lua_strings.Loader)
+
+			err := L.DoString(`
+				local strings = require("strings")
+				local seen = {}
+				local function fn(r, char, offset)
+					seen[#seen+1] = r .. ":" .. char .. ":" .. offset
+				end
+				function test_args(name, s)
+					local ret
+					if name == "Map" then
+						ret = strings.Map(fn, s)
+					elseif name == "FieldsFuncSeq" then
+						for _ in strings.FieldsFuncSeq(s, fn) do end
+					else
+						ret = strings[name](s, fn)
+					end
+					return table.concat(seen, " "), ret
+				end
+			`)
+			require.NoError(t, err)
+
+			args := []lua.LValue{
+				lua.LString(tt.fn),
+				lua.LString(tt.s),
+			}
+			got := callLuaFuncN(t, L, "test_args", args, 2)
+
+			require.Equal(t, tt.expected, got[0].String(),
+				"case %d: %s(%q) callback arguments", i, tt.fn, tt.s)
+			if tt.result != nil {
+				require.Equal(t, tt.result, got[1],
+					"case %d: %s(%q) result", i, tt.fn, tt.s)
+			}
+		})
+	}
+}
+
+func TestFuncCallbackErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		msgs []string
+	}{
+		{
+			`strings.IndexFunc("abc", function(r) error("boom") end)`,
+			[]string{"strings.IndexFunc: callback failed on rune 'a' (U+0061)", "boom", "stack traceback"},
+		},
+		{
+			`strings.FieldsFunc("x y", function(r) error("boom") end)`,
+			[]string{"strings.FieldsFunc: callback failed on rune 'x' (U+0078)", "boom"},
+		},
+		{
+			`strings.TrimFunc("世界", function(r) error({}) end)`,
+			[]string{"strings.TrimFunc: callback failed on rune '世' (U+4E16)", "table"},
+		},
+		{
+			`strings.Map(function(r) error("boom") end, "abc")`,
+			[]string{"strings.Map: callback failed on rune 'a' (U+0061)", "boom"},
+		},
+		{
+			`strings.Map(function(r) return true end, "abc")`,
+			[]string{"strings.Map: callback failed on rune 'a' (U+0061)", "number or string expected as result, got boolean"},
+		},
+		{
+			`strings.Map(function(r) return {} end, "abc")`,
+			[]string{"strings.Map: callback failed on rune 'a' (U+0061)", "number or string expected as result, got table"},
+		},
+		{
+			`for s in strings.FieldsFuncSeq("a,b", function(r) error("boom") end) do end`,
+			[]string{"strings.FieldsFuncSeq: callback failed on rune 'a' (U+0061)", "boom"},
+		},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		// the failure is an ordinary Lua error caught by pcall
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			local ok, err = pcall(function() %s end)
+			assert(not ok)
+			message = tostring(err)
+		`, tests[i].code))
+		require.NoError(t, err, "case %d: %s", i, tests[i].code)
+
+		message := L.GetGlobal("message").String()
+		for _, msg := range tests[i].msgs {
+			require.Contains(t, message, msg, "case %d: %s", i, tests[i].code)
+		}
 
-			expected := strings.FieldsFunc(tt.s, tt.goFunc)
+		L.Close()
+	}
+}
 
-			testL.Push(testL.GetGlobal("test_FieldsFunc"))
-			testL.Push(lua.LString(tt.s))
-			testL.Call(1, 1)
+func TestFuncCallbackTruthiness(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected lua.LValue
+	}{
+		{`strings.IndexFunc("ab,c", function(r) return string.find(",;", string.char(r), 1, true) end)`, lua.LNumber(2)},
+		{`strings.IndexFunc("abc", function(r) return nil end)`, lua.LNumber(-1)},
+		{`strings.IndexFunc("abc", function(r) return 0 end)`, lua.LNumber(0)},
+		{`strings.ContainsFunc("abc", function(r) end)`, lua.LFalse},
+		{`strings.ContainsFunc("abc", function(r) return "" end)`, lua.LTrue},
+		{`strings.TrimFunc("xxhixx", function(r) return r == 120 and r end)`, lua.LString("hi")},
+		{`#strings.FieldsFunc("a1b2c", function(r) return tonumber(string.char(r)) end)`, lua.LNumber(3)},
+	}
 
-			resultTable := testL.CheckTable(-1)
-			got := make([]string, 0, resultTable.Len())
-			resultTable.ForEach(func(_, value lua.LValue) {
-				got = append(got, value.String())
-			})
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
 
-			require.Equal(t, expected, got,
-				"case %d: Lua returned %v but Go returned %v (string: %q, func: %q)",
-				i, got, expected, tt.s, tt.luaFunc)
-		})
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			result = %s
+		`, tests[i].code))
+		require.NoError(t, err, "case %d: %s", i, tests[i].code)
+
+		got := L.GetGlobal("result")
+		require.Equal(t, tests[i].expected, got, "case %d: %s", i, tests[i].code)
+
+		L.Close()
 	}
 }
 
+func TestIterators(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected []string
+	}{
+		{`strings.SplitIter("a,b,,c", ",")`, strings.Split("a,b,,c", ",")},
+		{`strings.SplitIter("", ",")`, strings.Split("", ",")},
+		{`strings.SplitIter("你好", "")`, strings.Split("你好", "")},
+		{`strings.SplitAfterIter("a,b,c", ",")`, strings.SplitAfter("a,b,c", ",")},
+		{`strings.FieldsIter("  a b\tc  ")`, strings.Fields("  a b\tc  ")},
+		{`strings.FieldsIter("")`, []string{}},
+		{`strings.FieldsFuncIter("a1b22c", "digit")`, []string{"a", "b", "c"}},
+		{`strings.FieldsFuncIter("a;b", function(r, c) return c == ";" end)`, []string{"a", "b"}},
+		{`strings.LinesIter("a\nb\r\n\nc")`, []string{"a\n", "b\r\n", "\n", "c"}},
+		{`strings.LinesIter("a\n")`, []string{"a\n"}},
+		{`strings.LinesIter("")`, []string{}},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			result = {}
+			for i, s in %s do
+				assert(i == #result + 1)
+				result[i] = s
+			end
+		`, tests[i].code))
+		require.NoError(t, err, "case %d: %s", i, tests[i].code)
+
+		got := toStringSlice(L.GetGlobal("result").(*lua.LTable))
+		require.Equal(t, tests[i].expected, got, "case %d: %s", i, tests[i].code)
+
+		L.Close()
+	}
+}
+
+func TestIteratorsBreak(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+
+	// the iterators stop early and resume across coroutines
+	err := L.DoString(`
+		local strings = require("strings")
+		local s = string.rep("line\n", 100000)
+
+		local n = 0
+		for i, line in strings.LinesIter(s) do
+			n = i
+			if i == 3 then break end
+		end
+		assert(n == 3)
+
+		local next = strings.FieldsFuncIter("a b c", function(r) return r == 32 end)
+		local co = coroutine.wrap(function()
+			coroutine.yield(next())
+			coroutine.yield(next())
+		end)
+		local i, f = co()
+		assert(i == 1 and f == "a")
+		local i, f = co()
+		assert(i == 2 and f == "b")
+		local i, f = next()
+		assert(i == 3 and f == "c")
+		assert(next() == nil)
+	`)
+	require.NoError(t, err)
+}
+
 func TestHasPrefix(t *testing.T) {
 	const luaFuncName = "HasPrefix"
 
@@ -813,8 +1388,6 @@ func TestIndexByte(t *testing.T) {
 		{"hello", ' '},
 		{"hello world", ' '},
 		{"hello", 255},
-		{"hello", -1},
-		{"hello", 256},
 		{"hello", 'H'},
 	}
 
@@ -981,14 +1554,11 @@ func TestIndexRune(t *testing.T) {
 		{"你好世界", '好'},
 		{"hello世界", '界'},
 		{"\u0000hello", 0},
-		{"hello", -1},
 		{"hello", 0x10FFFF},
 		{"hello\U0010FFFF", 0x10FFFF},
-		{"hello", 0xD800},
 		{"αβγδ", 'β'},
 		{"hello", 256},
 		{"hello\U0010FFFF", 0x10FFFF},
-		{"", -1},
 		{"\uFFFD", 0xFFFD},
 	}
 
@@ -1007,6 +1577,54 @@ func TestIndexRune(t *testing.T) {
 	}
 }
 
+func TestRuneByteArgs(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected lua.LValue
+		err      string
+	}{
+		{`strings.ContainsRune("café", "é")`, lua.LTrue, ""},
+		{`strings.ContainsRune("cafe", "é")`, lua.LFalse, ""},
+		{`strings.IndexRune("你好世界", "世")`, lua.LNumber(6), ""},
+		{`strings.IndexRune("hello", "l")`, lua.LNumber(2), ""},
+		{`strings.IndexByte("hello", "o")`, lua.LNumber(4), ""},
+		{`strings.IndexByte("a\255", "\255")`, lua.LNumber(1), ""},
+		{`strings.LastIndexByte("hello", "l")`, lua.LNumber(3), ""},
+		{`strings.LastIndexByte("hello", 255)`, lua.LNumber(-1), ""},
+
+		{`strings.IndexRune("hello", -1)`, nil, "invalid rune -1"},
+		{`strings.IndexRune("hello", 0xD800)`, nil, "invalid rune 55296"},
+		{`strings.ContainsRune("hello", 0x110000)`, nil, "invalid rune 1114112"},
+		{`strings.ContainsRune("hello", 1.5)`, nil, "invalid rune 1.5"},
+		{`strings.IndexRune("hello", "ll")`, nil, "single character string expected"},
+		{`strings.IndexRune("hello", "")`, nil, "single character string expected"},
+		{`strings.IndexRune("hello", "\255")`, nil, "single character string expected"},
+		{`strings.IndexRune("hello", {})`, nil, "number expected, got table"},
+		{`strings.IndexByte("hello", 256)`, nil, "byte out of range"},
+		{`strings.IndexByte("hello", -1)`, nil, "byte out of range"},
+		{`strings.LastIndexByte("hello", "é")`, nil, "single byte string expected"},
+		{`strings.LastIndexByte("hello")`, nil, "number expected, got nil"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			result = %s
+		`, tests[i].code))
+		if tests[i].err != "" {
+			require.ErrorContains(t, err, tests[i].err, "case %d: %s", i, tests[i].code)
+		} else {
+			require.NoError(t, err, "case %d: %s", i, tests[i].code)
+			require.Equal(t, tests[i].expected, L.GetGlobal("result"), "case %d: %s", i, tests[i].code)
+		}
+
+		L.Close()
+	}
+}
+
 func TestJoin(t *testing.T) {
 	const luaFuncName = "Join"
 
@@ -1055,6 +1673,49 @@ func TestJoin(t *testing.T) {
 	}
 }
 
+func TestJoinValues(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+		err      string
+	}{
+		{`strings.Join({1, 2.5, "x"}, ",")`, "1,2.5,x", ""},
+		{`strings.Join({1, 2, x = "y"}, ",")`, "1,2", ""},
+		{`strings.Join({"c", "b", "a"}, "")`, "cba", ""},
+		{`strings.Join({setmetatable({}, {__tostring = function() return "obj" end}), "s"}, "-")`, "obj-s", ""},
+		{`strings.Join({strings.NewBuilder()}, "-")`, "", ""},
+		{`strings.Join(",")`, "", ""},
+		{`strings.Join(", ", "a", 1, "b")`, "a, 1, b", ""},
+		{`strings.Join("", setmetatable({}, {__tostring = function() return "t" end}), 2)`, "t2", ""},
+
+		{`strings.Join({"a", true}, ",")`, "", "invalid value (at index 2) in table for 'Join'"},
+		{`strings.Join({"a", {}}, ",")`, "", "invalid value (at index 2) in table for 'Join'"},
+		{`strings.Join({setmetatable({}, {__tostring = function() return 1 end})}, ",")`, "", "'__tostring' must return a string"},
+		{`strings.Join(",", "a", nil)`, "", "bad argument #3 to Join (string expected, got nil)"},
+		{`strings.Join({"a"})`, "", "string expected, got nil"},
+		{`strings.Join(nil, ",")`, "", "bad argument #1 to Join (table expected, got nil)"},
+		{`strings.Join(1, "a")`, "", "bad argument #1 to Join (table expected, got number)"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			result = %s
+		`, tests[i].code))
+		if tests[i].err != "" {
+			require.ErrorContains(t, err, tests[i].err, "case %d: %s", i, tests[i].code)
+		} else {
+			require.NoError(t, err, "case %d: %s", i, tests[i].code)
+			require.Equal(t, lua.LString(tests[i].expected), L.GetGlobal("result"), "case %d: %s", i, tests[i].code)
+		}
+
+		L.Close()
+	}
+}
+
 func TestLastIndex(t *testing.T) {
 	const luaFuncName = "LastIndex"
 
@@ -1160,8 +1821,6 @@ func TestLastIndexByte(t *testing.T) {
 		{"hello world", ' '},
 		{"aaa", 'a'},
 		{"hello", 255},
-		{"hello", -1},
-		{"hello", 256},
 		{"hello", 'H'},
 		{"abc\xff", 255},
 	}
@@ -1336,6 +1995,38 @@ func TestLastIndexFunc(t *testing.T) {
 	}
 }
 
+func TestLines(t *testing.T) {
+	const luaFuncName = "Lines"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s     string
+		lines []string
+	}{
+		{"", []string{}},
+		{"hello", []string{"hello"}},
+		{"hello\n", []string{"hello\n"}},
+		{"a\nb\nc", []string{"a\n", "b\n", "c"}},
+		{"a\n\nb\n", []string{"a\n", "\n", "b\n"}},
+		{"\n", []string{"\n"}},
+		{"a\r\nb", []string{"a\r\n", "b"}},
+		{"你好\n世界", []string{"你好\n", "世界"}},
+	}
+
+	for i := range tests {
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+		}
+		got := callLuaSeq(t, L, luaFuncName, args)
+
+		require.Equal(t, tests[i].lines, got,
+			"case %d: Lua returned %q but expected %q (string: %q)",
+			i, got, tests[i].lines, tests[i].s)
+	}
+}
+
 func TestMap(t *testing.T) {
 	const luaFuncName = "Map"
 
@@ -1569,6 +2260,80 @@ func TestMap(t *testing.T) {
 	}
 }
 
+func TestMapResults(t *testing.T) {
+	tests := []struct {
+		s        string
+		luaFunc  string
+		expected string
+	}{
+		{"abc", `function(r) return nil end`, ""},
+		{"abc", `function(r) return false end`, ""},
+		{"abc", `function(r) return -1 end`, ""},
+		{"a-b-c", `function(r) if r == 45 then return nil end return r end`, "abc"},
+		{"a&b", `function(r) if r == 38 then return "&amp;" end return r end`, "a&amp;b"},
+		{"abc", `function(r) return string.char(r, r) end`, "aabbcc"},
+		{"a,b", `function(r) if r == 44 then return "" end return string.char(r) end`, "ab"},
+		{"你好", `function(r) return "[" .. r .. "]" end`, "[20320][22909]"},
+		{"ab", `function(r) return 0x4E16 end`, "世世"},
+		{"ab", `function(r) return 0x10FFFF end`, "\U0010FFFF\U0010FFFF"},
+		{"ab", `function(r) return 66.0 end`, "BB"},
+		{"ab", `function(r) return -0.5 end`, ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d/string=%q", i, tt.s), func(t *testing.T) {
+			L := lua.NewState()
+			defer L.Close()
+
+			L.PreloadModule("strings", lua_strings.Loader)
+
+			err := L.DoString(fmt.Sprintf(`
+				local strings = require("strings")
+				function test_Map(s)
+					return strings.Map(%s, s)
+				end
+			`, tt.luaFunc))
+			require.NoError(t, err)
+
+			args := []lua.LValue{
+				lua.LString(tt.s),
+			}
+			got := callLuaFunc(t, L, "test_Map", args, func(L *lua.LState, idx int) string {
+				return L.ToString(idx)
+			})
+
+			require.Equal(t, tt.expected, got,
+				"case %d: Lua returned %q but expected %q (string: %q, func: %q)",
+				i, got, tt.expected, tt.s, tt.luaFunc)
+		})
+	}
+
+	// numbers that are not a valid rune are not silently converted
+	errTests := []struct {
+		luaFunc string
+		msg     string
+	}{
+		{`function(r) return 65.7 end`, "invalid rune result 65.7"},
+		{`function(r) return 1e20 end`, "invalid rune result 1e+20"},
+		{`function(r) return 0x110000 end`, "invalid rune result 1114112"},
+		{`function(r) return 0xD800 end`, "invalid rune result 55296"},
+	}
+
+	for i, tt := range errTests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			strings.Map(%s, "a")
+		`, tt.luaFunc))
+		require.ErrorContains(t, err, "strings.Map: callback failed on rune 'a' (U+0061): "+tt.msg,
+			"case %d: %s", i, tt.luaFunc)
+
+		L.Close()
+	}
+}
+
 func TestRepeat(t *testing.T) {
 	const luaFuncName = "Repeat"
 
@@ -1663,6 +2428,46 @@ func TestReplace(t *testing.T) {
 	}
 }
 
+func TestReplaceAll(t *testing.T) {
+	const luaFuncName = "ReplaceAll"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s   string
+		old string
+		new string
+	}{
+		{"", "", ""},
+		{"hello", "", "x"},
+		{"hello", "l", "L"},
+		{"hello hello", "hello", "hi"},
+		{"你好世界世界", "世界", "朋友"},
+		{"aaa", "a", "b"},
+		{"", "a", "b"},
+		{"hello", "hello", ""},
+		{"hello", "e", "ee"},
+	}
+
+	for i := range tests {
+		expected := strings.ReplaceAll(tests[i].s, tests[i].old, tests[i].new)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].old),
+			lua.LString(tests[i].new),
+		}
+		got := callLuaFunc(t, L, luaFuncName, args, func(L *lua.LState, idx int) string {
+			return L.ToString(idx)
+		})
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %q but Go returned %q (string: %q, old: %q, new: %q)",
+			i, got, expected, tests[i].s, tests[i].old, tests[i].new)
+	}
+}
+
 func TestSplit(t *testing.T) {
 	const luaFuncName = "Split"
 
@@ -1807,6 +2612,43 @@ func TestSplitAfterN(t *testing.T) {
 	}
 }
 
+func TestSplitAfterSeq(t *testing.T) {
+	const luaFuncName = "SplitAfterSeq"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s   string
+		sep string
+	}{
+		{"", ""},
+		{"", ","},
+		{"a,b,c", ","},
+		{"a,b,c,", ","},
+		{"abc", ""},
+		{"你好世界", ""},
+		{"a\nb\nc\n", "\n"},
+		{"hello", "xyz"},
+		{"\xffa", ""},
+	}
+
+	for i := range tests {
+		// SplitAfterSeq yields the same pieces as SplitAfter
+		expected := strings.SplitAfter(tests[i].s, tests[i].sep)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].sep),
+		}
+		got := callLuaSeq(t, L, luaFuncName, args)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %q but Go returned %q (string: %q, sep: %q)",
+			i, got, expected, tests[i].s, tests[i].sep)
+	}
+}
+
 func TestSplitN(t *testing.T) {
 	const luaFuncName = "SplitN"
 
@@ -1858,6 +2700,148 @@ func TestSplitN(t *testing.T) {
 	}
 }
 
+func TestListDestination(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+		err      string
+	}{
+		{`local t = {"x", "y", "z", "w"}; local r, n = strings.Split("a,b", ",", t); return tostring(r == t) .. n .. #t .. table.concat(t, "|")`, "true22a|b", ""},
+		{`local t = {}; local r, n = strings.Fields(" a  b c ", t); return tostring(r == t) .. n .. table.concat(t, "|")`, "true3a|b|c", ""},
+		{`local t = {"old"}; local _, n = strings.FieldsFunc("a1b2", function(r) return r >= 48 and r <= 57 end, t); return n .. table.concat(t, "|")`, "2a|b", ""},
+		{`local t = {}; local _, n = strings.SplitAfter("a,b", ",", t); return n .. table.concat(t, "|")`, "2a,|b", ""},
+		{`local t = {1, 2, 3}; local _, n = strings.SplitN("a,b,c", ",", 2, t); return n .. table.concat(t, "|")`, "2a|b,c", ""},
+		{`local t = {1, 2, 3}; local _, n = strings.SplitAfterN("a,b,c", ",", 0, t); return n .. #t`, "00", ""},
+		{`local t = {1, 2, 3}; local _, n = strings.Runes("hé", t); return n .. table.concat(t, "|")`, "2104|233", ""},
+		{`local t = {"x", k = "v"}; strings.Split("a", ",", t); return t[1] .. t.k`, "av", ""},
+		{`local t = strings.Split("a,b", ",", nil); return #t`, "2", ""},
+		{`local t = {}; for i = 1, 3 do strings.Split(string.rep("a,", 3 - i), ",", t) end; return #t .. t[1]`, "1", ""},
+
+		{`return strings.Split("a", ",", "x")`, "", "bad argument #3 to Split (table expected, got string)"},
+		{`return strings.Runes("a", 1)`, "", "bad argument #2 to Runes (table expected, got number)"},
+	}
+
+	for i := range tests {
+		L := lua.NewState()
+		L.PreloadModule("strings", lua_strings.Loader)
+
+		err := L.DoString(fmt.Sprintf(`
+			local strings = require("strings")
+			result = (function() %s end)()
+		`, tests[i].code))
+		if tests[i].err != "" {
+			require.ErrorContains(t, err, tests[i].err, "case %d: %s", i, tests[i].code)
+		} else {
+			require.NoError(t, err, "case %d: %s", i, tests[i].code)
+			require.Equal(t, tests[i].expected, L.GetGlobal("result").String(), "case %d: %s", i, tests[i].code)
+		}
+
+		L.Close()
+	}
+}
+
+func TestSplitNv(t *testing.T) {
+	tests := []struct {
+		s   string
+		sep string
+		n   int
+	}{
+		{"", "", 2},
+		{"", "=", 2},
+		{"", "=", 0},
+		{"k=v", "=", 2},
+		{"k=v=w", "=", 2},
+		{"k=v=w", "=", 3},
+		{"k=v=w", "=", 4},
+		{"k", "=", 2},
+		{"k=", "=", 2},
+		{"a::b::c", "::", 3},
+		{"abc", "", 2},
+		{"你好世界", "", 3},
+		{"ab", "", 5},
+		{"a,b,c", ",", 1},
+		{"a,b,c", ",", 0},
+	}
+
+	for _, name := range []string{"SplitNv", "SplitAfterNv"} {
+		L := setupLuaTest(t, name)
+
+		for i := range tests {
+			// the results must match the tables from SplitN and SplitAfterN
+			var expected []string
+			if name == "SplitNv" {
+				expected = strings.SplitN(tests[i].s, tests[i].sep, tests[i].n)
+			} else {
+				expected = strings.SplitAfterN(tests[i].s, tests[i].sep, tests[i].n)
+			}
+
+			L.Push(L.GetGlobal(name))
+			L.Push(lua.LString(tests[i].s))
+			L.Push(lua.LString(tests[i].sep))
+			L.Push(lua.LNumber(tests[i].n))
+			L.Call(3, lua.MultRet)
+
+			got := []string{}
+			for j := 1; j <= L.GetTop(); j++ {
+				got = append(got, L.CheckString(j))
+			}
+			L.SetTop(0)
+
+			if expected == nil {
+				expected = []string{}
+			}
+			require.Equal(t, expected, got, "%s case %d: %+v", name, i, tests[i])
+		}
+
+		err := L.DoString(name + `("a=b", "=", -1)`)
+		require.ErrorContains(t, err, "bad argument #3 to "+name+" (negative count)")
+
+		// the pieces go on the stack, a large count is rejected up front
+		require.NoError(t, L.DoString(`assert(select("#", `+name+`(string.rep("a,", 300), ",", 256)) == 256)`))
+		err = L.DoString(name + `(string.rep("a,", 100000), ",", 1000000)`)
+		require.ErrorContains(t, err, "bad argument #3 to "+name+" (too many results, count over 256)")
+
+		L.Close()
+	}
+}
+
+func TestSplitSeq(t *testing.T) {
+	const luaFuncName = "SplitSeq"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s   string
+		sep string
+	}{
+		{"", ""},
+		{"", ","},
+		{"a,b,c", ","},
+		{",a,,b,", ","},
+		{"abc", ""},
+		{"你好世界", ""},
+		{"a::b::c", "::"},
+		{"hello", "xyz"},
+		{"\xffa", ""},
+	}
+
+	for i := range tests {
+		// SplitSeq yields the same pieces as Split
+		expected := strings.Split(tests[i].s, tests[i].sep)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].sep),
+		}
+		got := callLuaSeq(t, L, luaFuncName, args)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %q but Go returned %q (string: %q, sep: %q)",
+			i, got, expected, tests[i].s, tests[i].sep)
+	}
+}
+
 func TestTitle(t *testing.T) {
 	const luaFuncName = "Title"
 
@@ -2026,6 +3010,41 @@ func TestToUpper(t *testing.T) {
 	}
 }
 
+func TestToValidUTF8(t *testing.T) {
+	const luaFuncName = "ToValidUTF8"
+
+	L := setupLuaTest(t, luaFuncName)
+	defer L.Close()
+
+	tests := []struct {
+		s           string
+		replacement string
+	}{
+		{"", ""},
+		{"hello", "?"},
+		{"a\xffb", "?"},
+		{"a\xff\xfeb", "�"},
+		{"\xed\xa0\x80", ""},
+		{"你好\x80世界", "_"},
+	}
+
+	for i := range tests {
+		expected := strings.ToValidUTF8(tests[i].s, tests[i].replacement)
+
+		args := []lua.LValue{
+			lua.LString(tests[i].s),
+			lua.LString(tests[i].replacement),
+		}
+		got := callLuaFunc(t, L, luaFuncName, args, func(L *lua.LState, idx int) string {
+			return L.ToString(idx)
+		})
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %q but Go returned %q (string: %q, replacement: %q)",
+			i, got, expected, tests[i].s, tests[i].replacement)
+	}
+}
+
 func TestTrim(t *testing.T) {
 	const luaFuncName = "Trim"
 
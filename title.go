@@ -0,0 +1,152 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// titleSmallWords are the words TitleCase leaves lowercase with
+// {small = true}.
+var titleSmallWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true,
+	"but": true, "by": true, "for": true, "in": true, "nor": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true,
+}
+
+type titleOptions struct {
+	small map[string]bool // lowercase words, nil for none
+	lower bool            // lowercase the rest of each word
+}
+
+// checkTitleOptions reads the options table of TitleCase at position n:
+//
+//	small: true for titleSmallWords, or a list of words
+//	lower: true to lowercase the rest of each word
+func checkTitleOptions(L *lua.LState, n int) titleOptions {
+	var opts titleOptions
+
+	tbl := L.OptTable(n, nil)
+	if tbl == nil {
+		return opts
+	}
+
+	switch small := tbl.RawGetString("small").(type) {
+	case lua.LBool:
+		if small {
+			opts.small = titleSmallWords
+		}
+	case *lua.LTable:
+		opts.small = make(map[string]bool)
+		for i := 1; i <= small.Len(); i++ {
+			word, ok := small.RawGetInt(i).(lua.LString)
+			if !ok {
+				L.ArgError(n, "string expected in 'small', got "+small.RawGetInt(i).Type().String())
+			}
+			opts.small[strings.ToLower(string(word))] = true
+		}
+	default:
+		if small != lua.LNil {
+			L.ArgError(n, "boolean or table expected as 'small', got "+small.Type().String())
+		}
+	}
+
+	switch lower := tbl.RawGetString("lower").(type) {
+	case lua.LBool:
+		opts.lower = bool(lower)
+	default:
+		if lower != lua.LNil {
+			L.ArgError(n, "boolean expected as 'lower', got "+lower.Type().String())
+		}
+	}
+
+	return opts
+}
+
+// newTitleCase returns TitleCase using the case mapping c, which may be
+// nil for the default Unicode mapping.
+func newTitleCase(c unicode.SpecialCase) lua.LGFunction {
+	return func(L *lua.LState) int {
+		s := L.CheckString(1)
+		opts := checkTitleOptions(L, 2)
+
+		ret := titleCase(s, c, opts)
+		return helper.RetString(L, ret)
+	}
+}
+
+// titleCase maps the first rune of every word of s to title case. Unlike
+// strings.Title, an apostrophe followed by a letter ("don't") and
+// combining marks belong to the word. Small words stay lowercase, except
+// the first and the last word.
+func titleCase(s string, c unicode.SpecialCase, opts titleOptions) string {
+	words := titleWords(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	last := 0
+	for k, w := range words {
+		b.WriteString(s[last:w[0]])
+		last = w[1]
+
+		word := s[w[0]:w[1]]
+		if opts.small != nil && k > 0 && k < len(words)-1 {
+			if lower := strings.ToLowerSpecial(c, word); opts.small[lower] {
+				b.WriteString(lower)
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(c.ToTitle(r))
+		if opts.lower {
+			b.WriteString(strings.ToLowerSpecial(c, word[size:]))
+		} else {
+			b.WriteString(word[size:])
+		}
+	}
+	b.WriteString(s[last:])
+
+	return b.String()
+}
+
+// titleWords returns the byte ranges of the words of s.
+func titleWords(s string) [][2]int {
+	var words [][2]int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !isTitleWordRune(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		for i += size; i < len(s); i += size {
+			r, size = utf8.DecodeRuneInString(s[i:])
+			if isTitleWordRune(r) {
+				continue
+			}
+			if r == '\'' || r == '’' {
+				next, _ := utf8.DecodeRuneInString(s[i+size:])
+				if unicode.IsLetter(next) {
+					continue
+				}
+			}
+			break
+		}
+		words = append(words, [2]int{start, i})
+	}
+	return words
+}
+
+func isTitleWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r)
+}
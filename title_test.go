@@ -0,0 +1,86 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		s        string
+		opts     string
+		expected string
+	}{
+		{"", "nil", ""},
+		{"hello world", "nil", "Hello World"},
+		{"don't stop", "nil", "Don't Stop"},
+		{"rock ’n’ roll", "nil", "Rock ’N’ Roll"},
+		{"o'neil's 'quote'", "nil", "O'neil's 'Quote'"},
+		{"well-known «élan» ǆungla", "nil", "Well-Known «Élan» ǅungla"},
+		{"école normale", "nil", "École Normale"},
+		{"3rd place", "nil", "3rd Place"},
+		{"hELLO wORLD", "nil", "HELLO WORLD"},
+		{"hELLO wORLD", "{lower = true}", "Hello World"},
+		{"the lord of the rings", "{small = true}", "The Lord of the Rings"},
+		{"THE LORD OF THE RINGS", "{small = true, lower = true}", "The Lord of the Rings"},
+		{"war and peace and", "{small = true}", "War and Peace And"},
+		{"a tale of two cities", "{small = {'Tale'}}", "A tale Of Two Cities"},
+		{"the end", "{small = false}", "The End"},
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	L.PreloadModule("strings", lua_strings.Loader)
+	require.NoError(t, L.DoString(`strings = require("strings")`))
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case=%d", i), func(t *testing.T) {
+			err := L.DoString(fmt.Sprintf(`result = strings.TitleCase(%q, %s)`, tt.s, tt.opts))
+			require.NoError(t, err)
+
+			got := L.GetGlobal("result")
+			require.Equal(t, lua.LString(tt.expected), got,
+				"case %d: Lua returned %q but expected %q (string: %q, opts: %s)",
+				i, got, tt.expected, tt.s, tt.opts)
+		})
+	}
+
+	errTests := []struct {
+		code string
+		err  string
+	}{
+		{`strings.TitleCase("a", 1)`, "bad argument #2 to TitleCase (table expected, got number)"},
+		{`strings.TitleCase("a", {small = "of"})`, "boolean or table expected as 'small', got string"},
+		{`strings.TitleCase("a", {small = {1}})`, "string expected in 'small', got number"},
+		{`strings.TitleCase("a", {lower = 1})`, "boolean expected as 'lower', got number"},
+	}
+	for i, tt := range errTests {
+		err := L.DoString(tt.code)
+		require.ErrorContains(t, err, tt.err, "case %d: %s", i, tt.code)
+	}
+}
+
+func TestTitleCaseLocale(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.PreloadWithOptions(L, lua_strings.Options{
+		CaseLocale: "turkish",
+	})
+
+	err := L.DoString(`
+		local strings = require("strings")
+		assert(strings.TitleCase("istanbul ILIK", {lower = true}) == "İstanbul Ilık")
+	`)
+	require.NoError(t, err)
+}
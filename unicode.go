@@ -0,0 +1,141 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"unicode"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func UnicodeLoader(L *lua.LState) int {
+	mod := L.NewTable()
+	L.SetFuncs(mod, unicodeFuncs)
+	L.SetField(mod, "MaxRune", lua.LNumber(unicode.MaxRune))
+	L.SetField(mod, "ReplacementChar", lua.LNumber(unicode.ReplacementChar))
+	L.SetField(mod, "MaxASCII", lua.LNumber(unicode.MaxASCII))
+	L.SetField(mod, "MaxLatin1", lua.LNumber(unicode.MaxLatin1))
+	L.SetField(mod, "Version", lua.LString(unicode.Version))
+	L.Push(mod)
+	return 1
+}
+
+func retRuneIs(L *lua.LState, is func(rune) bool) int {
+	r := checkRune(L, 1)
+
+	ret := is(r)
+	return helper.RetBool(L, ret)
+}
+
+func retRuneTo(L *lua.LState, to func(rune) rune) int {
+	r := checkRune(L, 1)
+
+	ret := to(r)
+	return helper.RetInt(L, int(ret))
+}
+
+// lookupRangeTable returns the name of a table of tables holding r, the
+// names rejected by accept are skipped. A nil accept takes every name.
+func lookupRangeTable(tables map[string]*unicode.RangeTable, r rune, accept func(name string) bool) (string, bool) {
+	for name, tab := range tables {
+		if (accept == nil || accept(name)) && unicode.Is(tab, r) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isSubcategory reports whether name is a two-letter category like "Lu",
+// these do not overlap. "LC" is the union of Lu, Ll and Lt.
+func isSubcategory(name string) bool {
+	return len(name) == 2 && name != "LC"
+}
+
+var unicodeFuncs = map[string]lua.LGFunction{
+	"Category": func(L *lua.LState) int {
+		r := checkRune(L, 1)
+
+		ret, ok := lookupRangeTable(unicode.Categories, r, isSubcategory)
+		if !ok {
+			L.Push(lua.LNil)
+			return 1
+		}
+		return helper.RetString(L, ret)
+	},
+	"In": func(L *lua.LState) int {
+		r := checkRune(L, 1)
+
+		for i := 2; i <= L.GetTop(); i++ {
+			f := checkRuneClass(L, i)
+			if f(r, 0, 0) {
+				return helper.RetBool(L, true)
+			}
+		}
+		return helper.RetBool(L, false)
+	},
+	"IsControl": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsControl)
+	},
+	"IsDigit": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsDigit)
+	},
+	"IsGraphic": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsGraphic)
+	},
+	"IsLetter": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsLetter)
+	},
+	"IsLower": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsLower)
+	},
+	"IsMark": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsMark)
+	},
+	"IsNumber": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsNumber)
+	},
+	"IsPrint": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsPrint)
+	},
+	"IsPunct": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsPunct)
+	},
+	"IsSpace": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsSpace)
+	},
+	"IsSymbol": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsSymbol)
+	},
+	"IsTitle": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsTitle)
+	},
+	"IsUpper": func(L *lua.LState) int {
+		return retRuneIs(L, unicode.IsUpper)
+	},
+	"Script": func(L *lua.LState) int {
+		r := checkRune(L, 1)
+
+		// scripts do not overlap
+		ret, ok := lookupRangeTable(unicode.Scripts, r, nil)
+		if !ok {
+			L.Push(lua.LNil)
+			return 1
+		}
+		return helper.RetString(L, ret)
+	},
+	"SimpleFold": func(L *lua.LState) int {
+		return retRuneTo(L, unicode.SimpleFold)
+	},
+	"ToLower": func(L *lua.LState) int {
+		return retRuneTo(L, unicode.ToLower)
+	},
+	"ToTitle": func(L *lua.LState) int {
+		return retRuneTo(L, unicode.ToTitle)
+	},
+	"ToUpper": func(L *lua.LState) int {
+		return retRuneTo(L, unicode.ToUpper)
+	},
+}
@@ -0,0 +1,207 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func setupUnicodeTest(t *testing.T, funcName string) *lua.LState {
+	t.Helper()
+
+	L := lua.NewState()
+	lua_strings.Preload(L)
+
+	if err := L.DoString(fmt.Sprintf(`
+		local unicode = require("strings.unicode")
+		%s = unicode.%s
+	`, funcName, funcName)); err != nil {
+		t.Fatal(err)
+	}
+
+	return L
+}
+
+var unicodeTestRunes = []rune{
+	0, 'a', 'Z', '5', ' ', '\t', '\n', '!', ',', '_', '$', '+',
+	'é', 'ß', 'İ', 'ı', 'ǅ', 'Σ', 'σ', 'ς', 'α', 'Ж', '世', '界',
+	'٣', '́', ' ', ' ', '　', '☺', 0x1F600,
+	0x7f, unicode.MaxRune, unicode.ReplacementChar,
+}
+
+func TestUnicodePredicates(t *testing.T) {
+	tests := []struct {
+		name   string
+		goFunc func(rune) bool
+	}{
+		{"IsControl", unicode.IsControl},
+		{"IsDigit", unicode.IsDigit},
+		{"IsGraphic", unicode.IsGraphic},
+		{"IsLetter", unicode.IsLetter},
+		{"IsLower", unicode.IsLower},
+		{"IsMark", unicode.IsMark},
+		{"IsNumber", unicode.IsNumber},
+		{"IsPrint", unicode.IsPrint},
+		{"IsPunct", unicode.IsPunct},
+		{"IsSpace", unicode.IsSpace},
+		{"IsSymbol", unicode.IsSymbol},
+		{"IsTitle", unicode.IsTitle},
+		{"IsUpper", unicode.IsUpper},
+	}
+
+	for _, tt := range tests {
+		L := setupUnicodeTest(t, tt.name)
+
+		for _, r := range unicodeTestRunes {
+			expected := tt.goFunc(r)
+
+			args := []lua.LValue{
+				lua.LNumber(r),
+			}
+			got := callLuaFunc(t, L, tt.name, args, toBool)
+
+			require.Equal(t, expected, got,
+				"%s: Lua returned %v but Go returned %v (rune: %U)",
+				tt.name, got, expected, r)
+		}
+
+		L.Close()
+	}
+}
+
+func TestUnicodeCaseMapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		goFunc func(rune) rune
+	}{
+		{"SimpleFold", unicode.SimpleFold},
+		{"ToLower", unicode.ToLower},
+		{"ToTitle", unicode.ToTitle},
+		{"ToUpper", unicode.ToUpper},
+	}
+
+	for _, tt := range tests {
+		L := setupUnicodeTest(t, tt.name)
+
+		for _, r := range unicodeTestRunes {
+			expected := int(tt.goFunc(r))
+
+			args := []lua.LValue{
+				lua.LNumber(r),
+			}
+			got := callLuaFunc(t, L, tt.name, args, toInt)
+
+			require.Equal(t, expected, got,
+				"%s: Lua returned %v but Go returned %v (rune: %U)",
+				tt.name, got, expected, r)
+		}
+
+		L.Close()
+	}
+}
+
+func TestUnicodeRuneArgs(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.Preload(L)
+
+	err := L.DoString(`
+		local unicode = require("strings.unicode")
+		assert(unicode.IsLetter("é"))
+		assert(unicode.IsUpper("Σ"))
+		assert(unicode.ToUpper("a") == 65)
+		assert(unicode.Script("世") == "Han")
+		assert(unicode.In("α", "Greek"))
+
+		local ok, err = pcall(unicode.IsLetter, -1)
+		assert(not ok and string.find(err, "invalid rune -1", 1, true))
+		local ok, err = pcall(unicode.ToLower, "ab")
+		assert(not ok and string.find(err, "single character string expected", 1, true))
+	`)
+	require.NoError(t, err)
+}
+
+func TestUnicodeIn(t *testing.T) {
+	L := setupUnicodeTest(t, "In")
+	defer L.Close()
+
+	tests := []struct {
+		r        rune
+		classes  []string
+		expected bool
+	}{
+		{'α', []string{"Greek", "Latin"}, true},
+		{'a', []string{"Greek", "Latin"}, true},
+		{'世', []string{"Greek", "Latin"}, false},
+		{'世', []string{"Han"}, true},
+		{'A', []string{"Lu"}, true},
+		{'a', []string{"Lu"}, false},
+		{' ', []string{"space"}, true},
+		{'a', nil, false},
+	}
+
+	for i := range tests {
+		args := []lua.LValue{
+			lua.LNumber(tests[i].r),
+		}
+		for _, class := range tests[i].classes {
+			args = append(args, lua.LString(class))
+		}
+		got := callLuaFunc(t, L, "In", args, toBool)
+
+		require.Equal(t, tests[i].expected, got,
+			"case %d: rune %q in %v", i, tests[i].r, tests[i].classes)
+	}
+
+	err := L.DoString(`In(65, "Klingon")`)
+	require.ErrorContains(t, err, "unknown character class 'Klingon'")
+}
+
+func TestUnicodeCategoryScript(t *testing.T) {
+	tests := []struct {
+		r        rune
+		category lua.LValue
+		script   lua.LValue
+	}{
+		{'a', lua.LString("Ll"), lua.LString("Latin")},
+		{'A', lua.LString("Lu"), lua.LString("Latin")},
+		{'5', lua.LString("Nd"), lua.LString("Common")},
+		{'α', lua.LString("Ll"), lua.LString("Greek")},
+		{'世', lua.LString("Lo"), lua.LString("Han")},
+		{' ', lua.LString("Zs"), lua.LString("Common")},
+		{'́', lua.LString("Mn"), lua.LString("Inherited")},
+		{0x10FFFD, lua.LString("Co"), lua.LNil},
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.Preload(L)
+	require.NoError(t, L.DoString(`
+		local strings = require("strings")
+		Category = strings.unicode.Category
+		Script = strings.unicode.Script
+	`))
+
+	for i := range tests {
+		args := []lua.LValue{
+			lua.LNumber(tests[i].r),
+		}
+
+		got := callLuaFuncN(t, L, "Category", args, 1)
+		require.Equal(t, tests[i].category, got[0], "case %d: category of %U", i, tests[i].r)
+
+		got = callLuaFuncN(t, L, "Script", args, 1)
+		require.Equal(t, tests[i].script, got[0], "case %d: script of %U", i, tests[i].r)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings
+
+import (
+	"math"
+	"unicode/utf8"
+
+	helper "github.com/chai2010/glua-helper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func UTF8Loader(L *lua.LState) int {
+	mod := L.NewTable()
+	L.SetFuncs(mod, utf8Funcs)
+	L.SetField(mod, "RuneError", lua.LNumber(utf8.RuneError))
+	L.SetField(mod, "RuneSelf", lua.LNumber(utf8.RuneSelf))
+	L.SetField(mod, "MaxRune", lua.LNumber(utf8.MaxRune))
+	L.SetField(mod, "UTFMax", lua.LNumber(utf8.UTFMax))
+	L.Push(mod)
+	return 1
+}
+
+var utf8Funcs = map[string]lua.LGFunction{
+	"DecodeLastRuneInString": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		r, size := utf8.DecodeLastRuneInString(s)
+		return helper.Return(L, int(r), size)
+	},
+	"DecodeRuneInString": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		r, size := utf8.DecodeRuneInString(s)
+		return helper.Return(L, int(r), size)
+	},
+	"EncodeRune": func(L *lua.LState) int {
+		r := checkUTF8Rune(L, 1)
+
+		ret := string(utf8.AppendRune(nil, r))
+		return helper.Return(L, ret, len(ret))
+	},
+	"FullRuneInString": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := utf8.FullRuneInString(s)
+		return helper.RetBool(L, ret)
+	},
+	"RuneCountInString": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := utf8.RuneCountInString(s)
+		return helper.RetInt(L, ret)
+	},
+	"RuneLen": func(L *lua.LState) int {
+		r := checkUTF8Rune(L, 1)
+
+		ret := utf8.RuneLen(r)
+		return helper.RetInt(L, ret)
+	},
+	"RuneStart": func(L *lua.LState) int {
+		b := checkByte(L, 1)
+
+		ret := utf8.RuneStart(b)
+		return helper.RetBool(L, ret)
+	},
+	"ValidRune": func(L *lua.LState) int {
+		r := checkUTF8Rune(L, 1)
+
+		ret := utf8.ValidRune(r)
+		return helper.RetBool(L, ret)
+	},
+	"ValidString": func(L *lua.LState) int {
+		s := L.CheckString(1)
+
+		ret := utf8.ValidString(s)
+		return helper.RetBool(L, ret)
+	},
+}
+
+// checkUTF8Rune returns the number at position n as a rune. Unlike
+// checkRune it keeps invalid runes, as the unicode/utf8 functions take
+// them, but a number outside the int32 range is -1 rather than wrapping
+// around to a valid rune. A number with a fraction is rejected.
+func checkUTF8Rune(L *lua.LState, n int) rune {
+	v := L.CheckNumber(n)
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return -1
+	}
+	if v != lua.LNumber(int(v)) {
+		L.ArgError(n, "number has no integer representation")
+	}
+	return rune(v)
+}
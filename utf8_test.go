@@ -0,0 +1,231 @@
+// Copyright 2017 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package strings_test
+
+import (
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	lua_strings "github.com/chai2010/glua-strings"
+)
+
+func setupUTF8Test(t *testing.T, funcName string) *lua.LState {
+	t.Helper()
+
+	L := lua.NewState()
+	lua_strings.Preload(L)
+
+	if err := L.DoString(fmt.Sprintf(`
+		local utf8 = require("strings.utf8")
+		%s = utf8.%s
+	`, funcName, funcName)); err != nil {
+		t.Fatal(err)
+	}
+
+	return L
+}
+
+var utf8TestStrings = []string{
+	"", "a", "hello", "你好世界", "αβγ", "a\u0000b", "☺😀",
+	"\xff", "a\xffb", "\xe4\xbd", "世\xe7", "\xed\xa0\x80", "\xf4\x90\x80\x80",
+}
+
+func TestUTF8RuneCountInString(t *testing.T) {
+	L := setupUTF8Test(t, "RuneCountInString")
+	defer L.Close()
+
+	for i, s := range utf8TestStrings {
+		expected := utf8.RuneCountInString(s)
+
+		args := []lua.LValue{
+			lua.LString(s),
+		}
+		got := callLuaFunc(t, L, "RuneCountInString", args, toInt)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (string: %q)",
+			i, got, expected, s)
+	}
+}
+
+func TestUTF8ValidString(t *testing.T) {
+	L := setupUTF8Test(t, "ValidString")
+	defer L.Close()
+
+	for i, s := range utf8TestStrings {
+		expected := utf8.ValidString(s)
+
+		args := []lua.LValue{
+			lua.LString(s),
+		}
+		got := callLuaFunc(t, L, "ValidString", args, toBool)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (string: %q)",
+			i, got, expected, s)
+	}
+}
+
+func TestUTF8FullRuneInString(t *testing.T) {
+	L := setupUTF8Test(t, "FullRuneInString")
+	defer L.Close()
+
+	for i, s := range utf8TestStrings {
+		expected := utf8.FullRuneInString(s)
+
+		args := []lua.LValue{
+			lua.LString(s),
+		}
+		got := callLuaFunc(t, L, "FullRuneInString", args, toBool)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (string: %q)",
+			i, got, expected, s)
+	}
+}
+
+func TestUTF8DecodeRuneInString(t *testing.T) {
+	tests := []struct {
+		name   string
+		goFunc func(string) (rune, int)
+	}{
+		{"DecodeRuneInString", utf8.DecodeRuneInString},
+		{"DecodeLastRuneInString", utf8.DecodeLastRuneInString},
+	}
+
+	for _, tt := range tests {
+		L := setupUTF8Test(t, tt.name)
+
+		for i, s := range utf8TestStrings {
+			r, size := tt.goFunc(s)
+
+			args := []lua.LValue{
+				lua.LString(s),
+			}
+			got := callLuaFuncN(t, L, tt.name, args, 2)
+
+			expected := []lua.LValue{lua.LNumber(r), lua.LNumber(size)}
+			require.Equal(t, expected, got,
+				"%s case %d: Lua returned %v but Go returned %v (string: %q)",
+				tt.name, i, got, expected, s)
+		}
+
+		L.Close()
+	}
+}
+
+var utf8TestRunes = []rune{
+	0, 'a', 0x7f, 0x80, 'é', 0x7ff, 0x800, '世', 0xD800, 0xDFFF, 0xFFFD,
+	0xFFFF, 0x10000, 0x1F600, utf8.MaxRune, utf8.MaxRune + 1, -1,
+}
+
+func TestUTF8EncodeRune(t *testing.T) {
+	L := setupUTF8Test(t, "EncodeRune")
+	defer L.Close()
+
+	for i, r := range utf8TestRunes {
+		buf := make([]byte, utf8.UTFMax)
+		n := utf8.EncodeRune(buf, r)
+
+		args := []lua.LValue{
+			lua.LNumber(r),
+		}
+		got := callLuaFuncN(t, L, "EncodeRune", args, 2)
+
+		expected := []lua.LValue{lua.LString(buf[:n]), lua.LNumber(n)}
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (rune: %U)",
+			i, got, expected, r)
+	}
+}
+
+func TestUTF8RuneLen(t *testing.T) {
+	L := setupUTF8Test(t, "RuneLen")
+	defer L.Close()
+
+	for i, r := range utf8TestRunes {
+		expected := utf8.RuneLen(r)
+
+		args := []lua.LValue{
+			lua.LNumber(r),
+		}
+		got := callLuaFunc(t, L, "RuneLen", args, toInt)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (rune: %U)",
+			i, got, expected, r)
+	}
+}
+
+func TestUTF8ValidRune(t *testing.T) {
+	L := setupUTF8Test(t, "ValidRune")
+	defer L.Close()
+
+	for i, r := range utf8TestRunes {
+		expected := utf8.ValidRune(r)
+
+		args := []lua.LValue{
+			lua.LNumber(r),
+		}
+		got := callLuaFunc(t, L, "ValidRune", args, toBool)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v (rune: %U)",
+			i, got, expected, r)
+	}
+}
+
+func TestUTF8RuneStart(t *testing.T) {
+	L := setupUTF8Test(t, "RuneStart")
+	defer L.Close()
+
+	for b := 0; b <= 0xFF; b++ {
+		expected := utf8.RuneStart(byte(b))
+
+		args := []lua.LValue{
+			lua.LNumber(b),
+		}
+		got := callLuaFunc(t, L, "RuneStart", args, toBool)
+
+		require.Equal(t, expected, got,
+			"case %d: Lua returned %v but Go returned %v", b, got, expected)
+	}
+
+	err := L.DoString(`RuneStart(256)`)
+	require.ErrorContains(t, err, "byte out of range")
+	err = L.DoString(`RuneStart(128.9)`)
+	require.ErrorContains(t, err, "byte out of range")
+}
+
+func TestUTF8RuneOutOfRange(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	lua_strings.Preload(L)
+
+	err := L.DoString(`
+		local utf8 = require("strings.utf8")
+
+		for _, r in ipairs({2^32 + 65, -2^32 + 65, 2^40, -2^40, 1e300}) do
+			assert(utf8.ValidRune(r) == false)
+			assert(utf8.RuneLen(r) == -1)
+			local s, n = utf8.EncodeRune(r)
+			assert(s == "\239\191\189" and n == 3)
+		end
+		assert(utf8.RuneLen(-1) == -1)
+
+		-- numbers with a fraction are not truncated
+		for _, f in ipairs({"EncodeRune", "RuneLen", "ValidRune"}) do
+			local ok, err = pcall(utf8[f], 65.9)
+			assert(not ok and err:find("number has no integer representation", 1, true), err)
+		end
+		assert(utf8.EncodeRune(65.0) == "A")
+	`)
+	require.NoError(t, err)
+}